@@ -0,0 +1,136 @@
+package toolsconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultAzureAuthorityHost is the authority host of the Azure public cloud. It can be changed with the
+// AZURE_AUTHORITY_HOST environment variable or the WithAuthorityHost option.
+const DefaultAzureAuthorityHost = "https://login.microsoftonline.com"
+
+var (
+	// ErrInvalidClientSecret is returned if the client secret of the subscription credential is wrong.
+	ErrInvalidClientSecret = errors.New("invalid client secret")
+	// ErrExpiredClientSecret is returned if the client secret of the subscription credential is expired.
+	ErrExpiredClientSecret = errors.New("expired client secret")
+	// ErrUnknownClient is returned if the client ID does not exist in the tenant.
+	ErrUnknownClient = errors.New("unknown client")
+	// ErrUnknownTenant is returned if the tenant does not exist.
+	ErrUnknownTenant = errors.New("unknown tenant")
+)
+
+// azureErrorCodes maps the AADSTS error codes to the typed errors.
+var azureErrorCodes = map[int]error{
+	7000215: ErrInvalidClientSecret,
+	7000222: ErrExpiredClientSecret,
+	700016:  ErrUnknownClient,
+	90002:   ErrUnknownTenant,
+}
+
+// AzureAuthError is returned if the token request for a subscription credential fails. Use errors.Is with
+// ErrInvalidClientSecret, ErrExpiredClientSecret, ErrUnknownClient or ErrUnknownTenant to check the reason.
+type AzureAuthError struct {
+	Subscription string
+	Err          error
+	TokenError   *TokenError
+}
+
+func (e *AzureAuthError) Error() string {
+	message := "azure authentication failed for subscription '" + e.Subscription + "'"
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message + " (" + e.TokenError.Error() + ")"
+}
+
+func (e *AzureAuthError) Is(target error) bool {
+	return e.Err != nil && e.Err == target
+}
+
+func (e *AzureAuthError) Unwrap() error {
+	return e.TokenError
+}
+
+// WithAuthorityHost sets the authority host used by AzureTokenProvider, e.g. to use another cloud or a local fake.
+func WithAuthorityHost(host string) TokenSourceOption {
+	return func(o *tokenSourceOptions) {
+		o.authorityHost = host
+	}
+}
+
+// AzureTokenProvider fetches access tokens for a service principal stored as AzureSubscriptionCredential.
+type AzureTokenProvider struct {
+	subscription string
+	source       *TokenSource
+}
+
+// TokenProvider creates a provider for access tokens of the given resource (e.g. 'https://management.azure.com') or
+// scope (e.g. 'https://vault.azure.net/.default') using the client credentials of the subscription.
+// Tokens are cached in memory and in the token cache file (see NewTokenSource).
+func (c AzureSubscriptionCredential) TokenProvider(resourceOrScope string, options ...TokenSourceOption) (*AzureTokenProvider, error) {
+	if c.TenantID == "" || c.ClientID == "" || c.ClientSecret == "" {
+		return nil, wrapErr(fmt.Errorf("incomplete subscription credential"), "subscription '"+c.Name+"'")
+	}
+	opts := tokenSourceOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	authorityHost := opts.authorityHost
+	if authorityHost == "" {
+		authorityHost = os.Getenv("AZURE_AUTHORITY_HOST")
+	}
+	if authorityHost == "" {
+		authorityHost = DefaultAzureAuthorityHost
+	}
+	credential := OAuth2Credential{
+		Name:         c.Name,
+		TokenURL:     strings.TrimSuffix(authorityHost, "/") + "/" + url.PathEscape(c.TenantID) + "/oauth2/v2.0/token",
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       []string{azureScope(resourceOrScope)},
+	}
+	return &AzureTokenProvider{
+		subscription: c.Name,
+		source:       NewTokenSource(credential, options...),
+	}, nil
+}
+
+// Token returns a valid access token, a cached one if available.
+func (p *AzureTokenProvider) Token(ctx context.Context) (*Token, error) {
+	token, err := p.source.Token(ctx)
+	return token, p.wrapErr(err)
+}
+
+// Refresh fetches a new access token, regardless of the cached one.
+func (p *AzureTokenProvider) Refresh(ctx context.Context) (*Token, error) {
+	token, err := p.source.Refresh(ctx)
+	return token, p.wrapErr(err)
+}
+
+func (p *AzureTokenProvider) wrapErr(err error) error {
+	var tokenErr *TokenError
+	if !errors.As(err, &tokenErr) {
+		return err
+	}
+	authErr := &AzureAuthError{Subscription: p.subscription, TokenError: tokenErr}
+	for _, code := range tokenErr.ErrorCodes {
+		if typedErr, ok := azureErrorCodes[code]; ok {
+			authErr.Err = typedErr
+			break
+		}
+	}
+	return authErr
+}
+
+// azureScope converts a resource to the '.default' scope of the v2.0 endpoint. Scopes are returned unchanged.
+func azureScope(resourceOrScope string) string {
+	if strings.HasSuffix(resourceOrScope, "/.default") || !strings.Contains(resourceOrScope, "://") {
+		return resourceOrScope
+	}
+	return strings.TrimSuffix(resourceOrScope, "/") + "/.default"
+}
@@ -0,0 +1,75 @@
+package toolsconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureSubscriptionCredential_TokenProvider(t *testing.T) {
+	var requestedPaths []string
+	var requestedScopes []string
+	authority := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		requestedPaths = append(requestedPaths, r.URL.Path)
+		requestedScopes = append(requestedScopes, r.PostForm.Get("scope"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostForm.Get("client_secret") {
+		case "client-secret":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "azure-token", "token_type": "Bearer", "expires_in": 3599})
+		case "expired-secret":
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": "invalid_client", "error_description": "AADSTS7000222: The provided client secret keys are expired.", "error_codes": []int{7000222}})
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": "invalid_client", "error_description": "AADSTS7000215: Invalid client secret provided.", "error_codes": []int{7000215}})
+		}
+	}))
+	defer authority.Close()
+
+	credential := AzureSubscriptionCredential{Name: subscriptionName01, SubscriptionID: "subscription-id", TenantID: "tenant-id", ClientID: "client-id", ClientSecret: "client-secret"}
+
+	t.Run("Token", func(t *testing.T) {
+		provider, err := credential.TokenProvider("https://management.azure.com/", WithAuthorityHost(authority.URL), WithoutTokenCacheFile())
+		require.NoError(t, err)
+		token, err := provider.Token(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "azure-token", token.AccessToken)
+		_, err = provider.Token(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{"/tenant-id/oauth2/v2.0/token"}, requestedPaths)
+		require.Equal(t, []string{"https://management.azure.com/.default"}, requestedScopes)
+	})
+
+	t.Run("InvalidSecret", func(t *testing.T) {
+		invalid := credential
+		invalid.ClientSecret = "wrong-secret"
+		provider, err := invalid.TokenProvider("https://vault.azure.net/.default", WithAuthorityHost(authority.URL), WithoutTokenCacheFile())
+		require.NoError(t, err)
+		_, err = provider.Token(context.Background())
+		require.ErrorIs(t, err, ErrInvalidClientSecret)
+		var tokenErr *TokenError
+		require.True(t, errors.As(err, &tokenErr))
+		require.Equal(t, "invalid_client", tokenErr.ErrorCode)
+	})
+
+	t.Run("ExpiredSecret", func(t *testing.T) {
+		expired := credential
+		expired.ClientSecret = "expired-secret"
+		provider, err := expired.TokenProvider("https://vault.azure.net/.default", WithAuthorityHost(authority.URL), WithoutTokenCacheFile())
+		require.NoError(t, err)
+		_, err = provider.Token(context.Background())
+		require.ErrorIs(t, err, ErrExpiredClientSecret)
+		require.False(t, errors.Is(err, ErrInvalidClientSecret))
+	})
+
+	t.Run("IncompleteCredential", func(t *testing.T) {
+		_, err := AzureSubscriptionCredential{Name: subscriptionName01, TenantID: "tenant-id"}.TokenProvider("https://management.azure.com")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
+	"github.com/daolis/toolsconfig"
+	"github.com/daolis/toolsconfig/commands"
+)
+
+// credentialKinds are the sections of the config file with credentials.
+var credentialKinds = []string{"servers", "azureSubscriptions", "generics", "databases", "kubernetes", "oauth2"}
+
+var credentialsCmd = &cobra.Command{
+	Use:     "credentials",
+	Aliases: []string{"creds"},
+	Short:   "Manage the credentials of the config file",
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(cmd.Help())
+	},
+}
+
+var credentialsListCmd = &cobra.Command{
+	Use:               "list [kind]",
+	Aliases:           []string{"ls"},
+	Short:             "List the credentials without secrets",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeKinds,
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(listCredentials(args))
+	},
+}
+
+var credentialsShowArgs struct {
+	resolve bool
+}
+
+var credentialsShowCmd = &cobra.Command{
+	Use:               "show <kind> <name>",
+	Short:             "Show where the values of a credential come from, secrets are masked",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeKinds,
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(showCredential(args[0], args[1], credentialsShowArgs.resolve))
+	},
+}
+
+var credentialsSetCmd = &cobra.Command{
+	Use:   "set <kind> <name> <field=value>...",
+	Short: "Add or change a credential",
+	Long: `Add or change a credential. The fields are the fields of the config file, e.g. 'username=me'. Fields of maps
+are set with 'fields.token=...' and lists like 'tags=team,prod' are separated by commas. Secret fields accept only
+secret references like 'password=env:MY_PASSWORD', so no secret ends up in the shell history. With an empty value
+like 'password=' the secret is read from the terminal without echo.`,
+	Args:              cobra.MinimumNArgs(3),
+	ValidArgsFunction: completeKinds,
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(setCredential(args[0], args[1], args[2:]))
+	},
+}
+
+func completeKinds(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return credentialKinds, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+type credentialEntry struct {
+	kind   string
+	name   string
+	labels toolsconfig.Labels
+}
+
+func credentialEntries(cfg toolsconfig.Configuration) []credentialEntry {
+	var entries []credentialEntry
+	for _, server := range cfg.GetAllServerCredentials() {
+		entries = append(entries, credentialEntry{kind: "servers", name: server.URL, labels: server.Labels})
+	}
+	for _, subscription := range cfg.GetAllAzureSubscriptionCredentials() {
+		entries = append(entries, credentialEntry{kind: "azureSubscriptions", name: subscription.Name, labels: subscription.Labels})
+	}
+	for _, generic := range cfg.GetAllGenericCredentials() {
+		entries = append(entries, credentialEntry{kind: "generics", name: generic.Key, labels: generic.Labels})
+	}
+	for _, database := range cfg.GetAllDatabaseCredentials() {
+		entries = append(entries, credentialEntry{kind: "databases", name: database.Name, labels: database.Labels})
+	}
+	for _, kubernetes := range cfg.GetAllKubernetesCredentials() {
+		entries = append(entries, credentialEntry{kind: "kubernetes", name: kubernetes.Name, labels: kubernetes.Labels})
+	}
+	for _, oauth2 := range cfg.GetAllOAuth2Credentials() {
+		entries = append(entries, credentialEntry{kind: "oauth2", name: oauth2.Name, labels: oauth2.Labels})
+	}
+	return entries
+}
+
+func listCredentials(kinds []string) error {
+	cfg, err := newConfig()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "KIND\tNAME\tALIASES\tTAGS\n")
+	for _, entry := range credentialEntries(cfg) {
+		if len(kinds) > 0 && kinds[0] != entry.kind {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.kind, entry.name, strings.Join(entry.labels.Aliases, ","),
+			strings.Join(entry.labels.Tags, ","))
+	}
+	return w.Flush()
+}
+
+// showCredential prints the explanation of the credential like 'config explain' of the tools.
+func showCredential(kind, name string, resolve bool) error {
+	cfg, err := newConfig()
+	if err != nil {
+		return err
+	}
+	explanation, err := cfg.Explain(kind, name, toolsconfig.ExplainResolve(resolve))
+	if err != nil {
+		return err
+	}
+	return commands.PrintExplanation(os.Stdout, explanation)
+}
+
+// setCredential sets the fields of the credential of the kind, a new credential is added if it does not exist.
+func setCredential(kind, name string, assignments []string) error {
+	cfg, err := newConfig()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case "servers":
+		// the entry with the same normalized url is replaced by SetServerCredentials
+		credential := toolsconfig.ServerCredential{URL: name}
+		for _, existing := range cfg.GetAllServerCredentials() {
+			if toolsconfig.SameServerURL(existing.URL, name) {
+				credential = existing
+			}
+		}
+		if err := applyFields(&credential, assignments); err != nil {
+			return err
+		}
+		err = cfg.SetServerCredentials(credential)
+	case "azureSubscriptions":
+		credential := toolsconfig.AzureSubscriptionCredential{Name: name}
+		for _, existing := range cfg.GetAllAzureSubscriptionCredentials() {
+			if existing.Name == name {
+				credential = existing
+			}
+		}
+		if err := applyFields(&credential, assignments); err != nil {
+			return err
+		}
+		err = cfg.SetAzureSubscriptionCredentials(credential)
+	case "generics":
+		credential := toolsconfig.GenericCredential{Key: name}
+		for _, existing := range cfg.GetAllGenericCredentials() {
+			if existing.Key == name {
+				credential = existing
+			}
+		}
+		if err := applyFields(&credential, assignments); err != nil {
+			return err
+		}
+		err = cfg.SetGenericCredentials(credential)
+	case "databases":
+		credential := toolsconfig.DatabaseCredential{Name: name}
+		for _, existing := range cfg.GetAllDatabaseCredentials() {
+			if existing.Name == name {
+				credential = existing
+			}
+		}
+		if err := applyFields(&credential, assignments); err != nil {
+			return err
+		}
+		err = cfg.SetDatabaseCredentials(credential)
+	case "kubernetes":
+		credential := toolsconfig.KubernetesCredential{Name: name}
+		for _, existing := range cfg.GetAllKubernetesCredentials() {
+			if existing.Name == name {
+				credential = existing
+			}
+		}
+		if err := applyFields(&credential, assignments); err != nil {
+			return err
+		}
+		err = cfg.SetKubernetesCredentials(credential)
+	case "oauth2":
+		credential := toolsconfig.OAuth2Credential{Name: name}
+		for _, existing := range cfg.GetAllOAuth2Credentials() {
+			if existing.Name == name {
+				credential = existing
+			}
+		}
+		if err := applyFields(&credential, assignments); err != nil {
+			return err
+		}
+		err = cfg.SetOAuth2Credentials(credential)
+	default:
+		return fmt.Errorf("unknown kind '%s' (%s)", kind, strings.Join(credentialKinds, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"kind": kind, "name": name}).Info("Saved credential")
+	return nil
+}
+
+// applyFields sets the fields of the credential with their yaml names from assignments like 'field=value'. Returns an
+// error for unknown fields. The values are converted to the types of the fields, the values of lists are separated by
+// commas. Secret fields accept only secret references or an empty value to read the secret, see secretValue.
+func applyFields(credential interface{}, assignments []string) error {
+	fields := credentialFields(reflect.TypeOf(credential).Elem())
+	data, err := yaml.Marshal(credential)
+	if err != nil {
+		return err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	for _, assignment := range assignments {
+		key, value, ok := strings.Cut(assignment, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid field '%s', expected <field>=<value>", assignment)
+		}
+		parent, child, nested := strings.Cut(key, ".")
+		field, known := fields[parent]
+		if known && field.Tag.Get("secret") == "true" {
+			var err error
+			if value, err = secretValue(key, value); err != nil {
+				return err
+			}
+		}
+		if nested {
+			named, _ := values[parent].(map[string]interface{})
+			if named == nil {
+				named = map[string]interface{}{}
+			}
+			named[child] = value
+			values[parent] = named
+			continue
+		}
+		if !known {
+			// rejected by the strict decoding below
+			values[key] = value
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Slice:
+			values[key] = strings.Split(value, ",")
+		case reflect.Int:
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid field '%s', expected a number", assignment)
+			}
+			values[key] = number
+		case reflect.Bool:
+			flag, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid field '%s', expected true or false", assignment)
+			}
+			values[key] = flag
+		default:
+			values[key] = value
+		}
+	}
+	data, err = yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(credential); err != nil {
+		return fmt.Errorf("invalid fields: %w", err)
+	}
+	return nil
+}
+
+// readSecret reads the value of a secret field from the terminal without echo.
+var readSecret = func(field string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no terminal to read the value of the secret field '%s'", field)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "%s: ", field)
+	value, err := term.ReadPassword(int(os.Stdin.Fd()))
+	_, _ = fmt.Fprintln(os.Stderr)
+	return string(value), err
+}
+
+// secretValue returns the value of a secret field: a secret reference like 'env:MY_PASSWORD', or the value read from
+// the terminal if the value is empty. Literal secrets are rejected, they would end up in the shell history.
+func secretValue(field, value string) (string, error) {
+	if value == "" {
+		return readSecret(field)
+	}
+	if !toolsconfig.IsSecretReference(value) || strings.HasPrefix(value, "raw:") {
+		return "", fmt.Errorf("secret field '%s' accepts only secret references like '%s=env:MY_SECRET', use '%s=' to enter the value", field, field, field)
+	}
+	return value, nil
+}
+
+// credentialFields returns the fields of a struct type by their yaml names, including the fields of inlined structs.
+func credentialFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if field.Type.Kind() == reflect.Struct && (field.Anonymous || (len(tag) > 1 && tag[1] == "inline")) {
+			for name, inlined := range credentialFields(field.Type) {
+				fields[name] = inlined
+			}
+			continue
+		}
+		switch tag[0] {
+		case "-":
+		case "":
+			fields[strings.ToLower(field.Name)] = field
+		default:
+			fields[tag[0]] = field
+		}
+	}
+	return fields
+}
+
+func init() {
+	credentialsCmd.AddCommand(credentialsListCmd)
+	credentialsShowCmd.Flags().BoolVar(&credentialsShowArgs.resolve, "resolve", false, "Resolve the secret references and variables, the values are masked")
+	credentialsCmd.AddCommand(credentialsShowCmd)
+	credentialsCmd.AddCommand(credentialsSetCmd)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/daolis/toolsconfig"
+)
+
+func TestApplyFields(t *testing.T) {
+	existing := toolsconfig.DatabaseCredential{Name: "pg", Host: "db.local", Params: map[string]string{"sslmode": "require"}}
+	tests := []struct {
+		name        string
+		assignments []string
+		want        toolsconfig.DatabaseCredential
+		wantErr     bool
+	}{
+		{name: "no fields", want: existing},
+		{name: "string and int", assignments: []string{"host=localhost", "port=5432", "password=file:/run/secrets/pg"},
+			want: toolsconfig.DatabaseCredential{Name: "pg", Host: "localhost", Port: 5432, Password: "file:/run/secrets/pg", Params: map[string]string{"sslmode": "require"}}},
+		{name: "string with leading zero", assignments: []string{"database=0123", "username=1e3"},
+			want: toolsconfig.DatabaseCredential{Name: "pg", Host: "db.local", Database: "0123", Username: "1e3", Params: map[string]string{"sslmode": "require"}}},
+		{name: "literal secret", assignments: []string{"password=1234"}, wantErr: true},
+		{name: "raw secret", assignments: []string{"password=raw:1234"}, wantErr: true},
+		{name: "read secret", assignments: []string{"password="},
+			want: toolsconfig.DatabaseCredential{Name: "pg", Host: "db.local", Password: "read:password", Params: map[string]string{"sslmode": "require"}}},
+		{name: "invalid int", assignments: []string{"port=default"}, wantErr: true},
+		{name: "value with equals", assignments: []string{"password=env:A=B"},
+			want: toolsconfig.DatabaseCredential{Name: "pg", Host: "db.local", Password: "env:A=B", Params: map[string]string{"sslmode": "require"}}},
+		{name: "map field", assignments: []string{"params.timeout=10"},
+			want: toolsconfig.DatabaseCredential{Name: "pg", Host: "db.local", Params: map[string]string{"sslmode": "require", "timeout": "10"}}},
+		{name: "lists", assignments: []string{"tags=team,prod", "aliases=db"},
+			want: toolsconfig.DatabaseCredential{Name: "pg", Host: "db.local", Params: map[string]string{"sslmode": "require"},
+				Labels: toolsconfig.Labels{Aliases: []string{"db"}, Tags: []string{"team", "prod"}}}},
+		{name: "unknown field", assignments: []string{"hostname=localhost"}, wantErr: true},
+		{name: "missing value", assignments: []string{"host"}, wantErr: true},
+	}
+	defer func(read func(string) (string, error)) { readSecret = read }(readSecret)
+	readSecret = func(field string) (string, error) { return "read:" + field, nil }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential := existing
+			credential.Params = map[string]string{"sslmode": "require"}
+			err := applyFields(&credential, tt.assignments)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, credential)
+		})
+	}
+}
+
+func TestParseFavouriteReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		wantTool  string
+		wantName  string
+		wantErr   bool
+	}{
+		{reference: "mytool:deploy", wantTool: "mytool", wantName: "deploy"},
+		{reference: "mytool:deploy:prod", wantTool: "mytool", wantName: "deploy:prod"},
+		{reference: "deploy", wantErr: true},
+		{reference: ":deploy", wantErr: true},
+		{reference: "mytool:", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			tool, name, err := parseFavouriteReference(tt.reference)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantTool, tool)
+			require.Equal(t, tt.wantName, name)
+		})
+	}
+}
+
+func TestRunFavouriteConfigLocation(t *testing.T) {
+	defer func() {
+		rootArgs.configDir, rootArgs.configFile = defaultConfigDir, defaultConfigFile
+	}()
+	rootArgs.configDir, rootArgs.configFile = t.TempDir(), defaultConfigFile
+	err := runFavourite("mytool:deploy", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "default config file")
+}
+
+func TestFavouriteArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		dryRun bool
+		yes    bool
+		want   []string
+	}{
+		{name: "no values", want: []string{"--run", "deploy"}},
+		{name: "flags before values", values: []string{"env=prod", "-v"}, dryRun: true, yes: true,
+			want: []string{"--run", "deploy", "--dry-run", "--yes", "--", "env=prod", "-v"}},
+		{name: "values", values: []string{"--help"}, want: []string{"--run", "deploy", "--", "--help"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, favouriteArgs("deploy", tt.values, tt.dryRun, tt.yes))
+		})
+	}
+}
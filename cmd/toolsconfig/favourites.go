@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+
+	"github.com/daolis/toolsconfig"
+	"github.com/daolis/toolsconfig/commands"
+)
+
+var favArgs struct {
+	tool string
+	sort string
+}
+
+var favCmd = &cobra.Command{
+	Use:     "fav",
+	Aliases: []string{"favourite", "favourites"},
+	Short:   "List the favourites of all tools",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		order, err := toolsconfig.ParseFavouriteOrder(favArgs.sort)
+		cobra.CheckErr(err)
+		cobra.CheckErr(listFavourites(favArgs.tool, order))
+	},
+}
+
+var runArgs struct {
+	dryRun bool
+	yes    bool
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <tool:name> [values]",
+	Short: "Run the favourite of a tool, the values are the values of the placeholders",
+	Args:  cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeFavouriteReferences(), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runFavourite(args[0], args[1:])
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// the tool printed the error
+			os.Exit(exitErr.ExitCode())
+		}
+		cobra.CheckErr(err)
+	},
+}
+
+// parseFavouriteReference splits a reference like 'mytool:deploy' into the tool and the name of the favourite.
+func parseFavouriteReference(reference string) (string, string, error) {
+	tool, name, ok := strings.Cut(reference, ":")
+	if !ok || tool == "" || name == "" {
+		return "", "", fmt.Errorf("invalid favourite '%s', expected <tool>:<name>", reference)
+	}
+	return tool, name, nil
+}
+
+func listFavourites(tool string, order toolsconfig.FavouriteOrder) error {
+	cfg, err := newConfig()
+	if err != nil {
+		return err
+	}
+	tools := cfg.FavouriteTools()
+	if tool != "" {
+		tools = []string{tool}
+	}
+	fmt.Printf("\nFavourites (execute with 'toolsconfig run [TOOL]:[NAME]')\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "TOOL\tNAME\tDESCRIPTION\tRUNS\tLAST RUN\tCOMMAND\n")
+	for _, tool := range tools {
+		favourites := cfg.GetFavourites(tool)
+		toolsconfig.SortFavourites(favourites, order)
+		for _, fav := range favourites {
+			_, _ = fmt.Fprintf(w, "%s\t%s%s%s\t%s\t%d\t%s\t%s\n", tool, chalk.Yellow, fav.Name, chalk.ResetColor, fav.Description,
+				fav.RunCount, commands.FormatTime(fav.LastRun), fav.CommandLine(tool))
+		}
+	}
+	return w.Flush()
+}
+
+// runFavourite runs the favourite with the binary of the tool found in the PATH. The tool reads the favourite from its
+// default config file, so favourites of other config files are not run.
+func runFavourite(reference string, values []string) error {
+	tool, name, err := parseFavouriteReference(reference)
+	if err != nil {
+		return err
+	}
+	if rootArgs.configDir != defaultConfigDir || rootArgs.configFile != defaultConfigFile {
+		return fmt.Errorf("tool '%s' reads the favourites of its default config file, run the favourites of other config files with '%s --run %s'", tool, tool, name)
+	}
+	cfg, err := newConfig()
+	if err != nil {
+		return err
+	}
+	if _, err := cfg.GetFavourite(tool, name); err != nil {
+		return fmt.Errorf("favourite '%s' of tool '%s' not found", name, tool)
+	}
+	binary, err := exec.LookPath(tool)
+	if err != nil {
+		return fmt.Errorf("tool '%s' not found: %w", tool, err)
+	}
+	command := exec.Command(binary, favouriteArgs(name, values, runArgs.dryRun, runArgs.yes)...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return command.Run()
+}
+
+// favouriteArgs returns the arguments of the tool to run the favourite. The values follow '--', so values starting
+// with '-' are not parsed as flags of the tool.
+func favouriteArgs(name string, values []string, dryRun, yes bool) []string {
+	args := []string{"--run", name}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	if yes {
+		args = append(args, "--yes")
+	}
+	if len(values) > 0 {
+		args = append(append(args, "--"), values...)
+	}
+	return args
+}
+
+// completeFavouriteReferences returns the references of all favourites.
+func completeFavouriteReferences() []string {
+	cfg, err := newConfig()
+	if err != nil {
+		return nil
+	}
+	var references []string
+	for _, tool := range cfg.FavouriteTools() {
+		for _, fav := range cfg.GetFavourites(tool) {
+			references = append(references, fmt.Sprintf("%s:%s\t%s", tool, fav.Name, fav.Description))
+		}
+	}
+	return references
+}
+
+func init() {
+	favCmd.Flags().StringVar(&favArgs.tool, "tool", "", "List only the favourites of the tool")
+	favCmd.Flags().StringVar(&favArgs.sort, "sort", string(toolsconfig.FavouriteOrderName), "Sort order (name, recent, most-used)")
+	runCmd.Flags().BoolVar(&runArgs.dryRun, "dry-run", false, "Print the commands of the favourite without running them")
+	runCmd.Flags().BoolVar(&runArgs.yes, "yes", false, "Run the favourite without asking for confirmation")
+}
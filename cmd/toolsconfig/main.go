@@ -0,0 +1,48 @@
+// Command toolsconfig is one entry point to the favourites of all tools using toolsconfig and to the credentials of
+// the configuration file.
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/daolis/toolsconfig"
+)
+
+const (
+	// defaultConfigDir and defaultConfigFile are the location of the config file read by the tools.
+	defaultConfigDir  = ".toolsconfig"
+	defaultConfigFile = "config.yaml"
+)
+
+var rootArgs struct {
+	configDir  string
+	configFile string
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "toolsconfig",
+	Short: "Favourites of all tools and credentials of the toolsconfig configuration",
+}
+
+// newConfig returns the configuration of the config file given by the flags, missing credentials are not added.
+func newConfig() (toolsconfig.Configuration, error) {
+	toolsconfig.ConfigFileLocation(rootArgs.configDir, rootArgs.configFile)
+	return toolsconfig.NewToolConfiguration(toolsconfig.UpdateConfig(false))
+}
+
+func main() {
+	err := rootCmd.Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&rootArgs.configDir, "config-dir", defaultConfigDir, "Directory of the config file, relative to the home directory if not absolute or '.'")
+	rootCmd.PersistentFlags().StringVar(&rootArgs.configFile, "config-file", defaultConfigFile, "Name of the config file")
+	rootCmd.AddCommand(favCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(credentialsCmd)
+}
@@ -1,9 +1,6 @@
 package commands
 
 import (
-	"os"
-	"strings"
-
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
@@ -13,6 +10,12 @@ import (
 var rootArgs struct {
 	saveName         string
 	runFavouriteName string
+	defaults         map[string]string
+	description      string
+	confirm          bool
+	positional       bool
+	dryRun           bool
+	yes              bool
 }
 
 var favCmd = &cobra.Command{
@@ -27,32 +30,234 @@ var favCmd = &cobra.Command{
 	},
 }
 
+var favListArgs struct {
+	sort string
+}
+
 var favListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List favourites",
 	Run: func(cmd *cobra.Command, args []string) {
-		cobra.CheckErr(listFavourites(cmd.Root().Name()))
+		order, err := toolsconfig.ParseFavouriteOrder(favListArgs.sort)
+		cobra.CheckErr(err)
+		cobra.CheckErr(listFavourites(cmd.Root().Name(), order))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var favShowCmd = &cobra.Command{
+	Use:               "show <name>",
+	Short:             "Show a favourite",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFavouriteArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(showFavourite(cmd.Root().Name(), args[0]))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var favRemoveCmd = &cobra.Command{
+	Use:               "rm <name>...",
+	Aliases:           []string{"delete"},
+	Short:             "Remove favourites",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeFavouriteArgs(-1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(removeFavourites(cmd.Root().Name(), args))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var favRenameCmd = &cobra.Command{
+	Use:               "rename <name> <new name>",
+	Aliases:           []string{"mv"},
+	Short:             "Rename a favourite",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeFavouriteArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(renameFavourite(cmd.Root().Name(), args[0], args[1]))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var favEditCmd = &cobra.Command{
+	Use:               "edit <name>",
+	Short:             "Edit the arguments of a favourite with $EDITOR (as yaml list)",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFavouriteArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(editFavourite(cmd.Root().Name(), args[0]))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var favCopyCmd = &cobra.Command{
+	Use:               "cp <name> <new name>",
+	Aliases:           []string{"copy"},
+	Short:             "Copy a favourite",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeFavouriteArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(copyFavourite(cmd.Root().Name(), args[0], args[1]))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var favChainArgs struct {
+	continueOnError bool
+}
+
+var favChainCmd = &cobra.Command{
+	Use:               "chain <name> <favourite>...",
+	Short:             "Save a chain running the favourites in sequence",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeFavouriteArgs(-1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(saveFavouriteChain(cmd.Root().Name(), args[0], args[1:], favChainArgs.continueOnError))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var favCheckCmd = &cobra.Command{
+	Use:               "check [name]...",
+	Short:             "Check that favourites match the commands and flags of this version (all if no names are given)",
+	ValidArgsFunction: completeFavouriteArgs(-1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(checkFavourites(cmd.Root(), args))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var favExportArgs struct {
+	format string
+	output string
+}
+
+var favExportCmd = &cobra.Command{
+	Use:               "export [name]...",
+	Short:             "Export favourites to share them (all if no names are given)",
+	ValidArgsFunction: completeFavouriteArgs(-1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(exportFavourites(cmd.Root(), args, favExportArgs.format, favExportArgs.output))
 	},
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// do not call PersistentPreRun from parent
 	},
 }
 
+var favImportArgs struct {
+	strategy string
+}
+
+var favImportCmd = &cobra.Command{
+	Use:   "import <file|->",
+	Short: "Import exported favourites from a file or stdin",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		strategy, err := toolsconfig.ParseImportStrategy(favImportArgs.strategy)
+		cobra.CheckErr(err)
+		cobra.CheckErr(importFavourites(cmd.Root(), args[0], strategy))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "History of the executed commands",
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(cmd.Help())
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var historyListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the executed commands, the last command is number 1",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(listHistory(cmd.Root().Name()))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var historyRerunCmd = &cobra.Command{
+	Use:   "rerun <number> [values]",
+	Short: "Run an executed command again, the values are the values of redacted secrets (name=value)",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(rerunHistory(cmd.Root(), args[0], args[1:]))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var historySaveCmd = &cobra.Command{
+	Use:   "save <number> <name>",
+	Short: "Save an executed command as favourite",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(saveHistory(cmd.Root().Name(), args[0], args[1]))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+// configOptions are the options of the tool, set with WithConfigOptions.
+var configOptions []toolsconfig.ConfigOption
+
 func newToolsConfig(options ...toolsconfig.ConfigOption) (toolsconfig.Configuration, error) {
-	return toolsconfig.NewToolConfiguration(options...)
+	return toolsconfig.NewToolConfiguration(append(append([]toolsconfig.ConfigOption{}, configOptions...), options...)...)
 }
 
 // AddToRootCommand adds all commands and flags to the given root command.
 // If you want to use the Run and PersistentPostRun functions, you need to add them using the
-//WithRunFunctions and WithPersistentPostRunFunctions options.
-//These functions are called after the internal toolconfig functions.
+// WithRunFunctions and WithPersistentPostRunFunctions options.
+// These functions are called after the internal toolconfig functions.
 // Commands:
 // * fav (Favourites)
 // * fav list (List favourites)
+// * fav show/rm/rename/edit/cp (Manage favourites)
+// * fav chain <name> <favourite>... (Save a chain of favourites)
+// * fav check [name]... (Check favourites against the commands and flags)
+// * fav export/import (Share favourites)
+// * history list/rerun/save (History of the executed commands, if enabled with WithHistory)
+// * config env (List the environment variables of the credentials)
+// * config explain <kind> <name> (Explain where the values of a credential come from)
 // Flags:
 // * --save <name> (Save favourite, persistent flag to use it on every sub command)
-// * --run <name> (Run favourite)
+// * --description <text> (Description of the saved favourite)
+// * --default <name=value> (Default values of placeholders of the saved favourite)
+// * --confirm (Ask for confirmation before the saved favourite runs)
+// * --positional (Replace '$1', '$2', ... of the saved favourite by the values given with --run, '$$' is a literal '$')
+// * --run <name> [values] (Run favourite, values are 'name=value' for '{{name}}' or positional for '$1', '$2', ...)
+// * --dry-run (Print the commands of the favourite instead of running them)
+// * --yes (Run the favourite without asking for confirmation)
 func AddToRootCommand(command *cobra.Command, opts ...commandOption) {
 	if command.HasParent() {
 		panic("AddToRootCommand can only be called with the root command!")
@@ -64,23 +269,19 @@ func AddToRootCommand(command *cobra.Command, opts ...commandOption) {
 	for _, opt := range opts {
 		opt(options)
 	}
+	configOptions = options.configOptions
+	historySize = options.historySize
+	newRootCommand = options.newRootCommand
 
 	persistentPostRun := func(cmd *cobra.Command, args []string) {
+		if ranFavourite {
+			// the commands of the favourite are recorded
+			ranFavourite = false
+		} else if err := recordHistory(cmd, args); err != nil {
+			log.WithError(err).Warn("Could not record the command in the history")
+		}
 		if len(rootArgs.saveName) != 0 {
-			var saveArgs []string
-			var removeNextToo bool
-			for _, arg := range os.Args[1:] {
-				if arg == "--save" {
-					removeNextToo = true
-					continue
-				}
-				if removeNextToo {
-					removeNextToo = false
-					continue
-				}
-				saveArgs = append(saveArgs, arg)
-			}
-			cobra.CheckErr(saveFavourite(cmd.Root().Name(), rootArgs.saveName, saveArgs))
+			cobra.CheckErr(saveFavourite(cmd.Root().Name(), toolsconfig.Favourite{Name: rootArgs.saveName, Args: savedArgs(cmd, args), Defaults: rootArgs.defaults, Description: rootArgs.description, Confirm: rootArgs.confirm, Positional: rootArgs.positional}))
 			log.WithField("name", rootArgs.saveName).Info("Saved command as favourite")
 			return
 		}
@@ -91,13 +292,8 @@ func AddToRootCommand(command *cobra.Command, opts ...commandOption) {
 
 	rootRun := func(cmd *cobra.Command, args []string) {
 		if len(rootArgs.runFavouriteName) != 0 {
-			cfg, err := toolsconfig.NewToolConfiguration()
-			cobra.CheckErr(err)
-			favourite, err := cfg.GetFavourite(cmd.Root().Name(), rootArgs.runFavouriteName)
-			cobra.CheckErr(err)
-			cmd.SetArgs(favourite.Args)
-			log.WithFields(log.Fields{"name": rootArgs.runFavouriteName, "args": strings.Join(favourite.Args, " ")}).Info("Running saved favourite")
-			cobra.CheckErr(cmd.Execute())
+			cobra.CheckErr(RunFavourite(cmd, rootArgs.runFavouriteName, args...))
+			ranFavourite = true
 			return
 		}
 		for _, runFn := range options.runFunctions {
@@ -106,21 +302,34 @@ func AddToRootCommand(command *cobra.Command, opts ...commandOption) {
 	}
 
 	command.PersistentFlags().StringVar(&rootArgs.saveName, "save", "", "Save the command with the given name!")
-	command.Flags().StringVar(&rootArgs.runFavouriteName, "run", "", "Run the saved favourite with the given name")
+	command.PersistentFlags().StringVar(&rootArgs.description, "description", "", "Description of the saved favourite")
+	command.PersistentFlags().StringToStringVar(&rootArgs.defaults, "default", nil, "Default values of the placeholders of the saved favourite (name=value)")
+	command.PersistentFlags().BoolVar(&rootArgs.confirm, "confirm", false, "Ask for confirmation before the saved favourite runs")
+	command.PersistentFlags().BoolVar(&rootArgs.positional, "positional", false, "Use '$1', '$2', ... of the saved favourite as positional placeholders, '$$' is a literal '$'")
+	command.Flags().StringVar(&rootArgs.runFavouriteName, "run", "", "Run the saved favourite with the given name, the following arguments are the values of the placeholders")
+	command.Flags().BoolVar(&rootArgs.dryRun, "dry-run", false, "Print the commands of the favourite given with --run without running them")
+	command.Flags().BoolVar(&rootArgs.yes, "yes", false, "Run the favourite given with --run without asking for confirmation")
 	_ = command.RegisterFlagCompletionFunc("run", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		cfg, err := newToolsConfig()
-		if err != nil {
-			panic(err)
+		return completeFavouriteNames(cmd)
+	})
+
+	validateArgs := command.Args
+	command.Args = func(cmd *cobra.Command, args []string) error {
+		if len(rootArgs.runFavouriteName) != 0 {
+			// values of the placeholders
+			return nil
 		}
-		favourites := cfg.GetFavourites(cmd.Root().Name())
-		favNames := make([]string, len(favourites))
-		for idx, favourite := range favourites {
-			favNames[idx] = favourite.Name
+		if validateArgs != nil {
+			return validateArgs(cmd, args)
 		}
-		return favNames, cobra.ShellCompDirectiveDefault
-	})
+		return cobra.NoArgs(cmd, args)
+	}
 
 	command.AddCommand(favCmd)
+	command.AddCommand(configCmd)
+	if historySize > 0 {
+		command.AddCommand(historyCmd)
+	}
 	command.PersistentPostRun = persistentPostRun
 	command.Run = rootRun
 }
@@ -140,6 +349,9 @@ type commandOption func(options *commandOptions)
 type commandOptions struct {
 	runFunctions               []func(cmd *cobra.Command, args []string)
 	persistentPostRunFunctions []func(cmd *cobra.Command, args []string)
+	configOptions              []toolsconfig.ConfigOption
+	historySize                int
+	newRootCommand             func() *cobra.Command
 }
 
 func WithRunFunctions(functions ...func(cmd *cobra.Command, args []string)) commandOption {
@@ -154,6 +366,59 @@ func WithPersistentPostRunFunctions(functions ...func(cmd *cobra.Command, args [
 	}
 }
 
+// WithConfigOptions sets the options used by the commands to read the configuration, e.g. toolsconfig.EnvPrefix(..).
+func WithConfigOptions(configOptions ...toolsconfig.ConfigOption) commandOption {
+	return func(options *commandOptions) {
+		options.configOptions = configOptions
+	}
+}
+
+// WithCommandTree sets the function creating the root command of the tool with all sub commands, e.g. the function
+// creating the command tree in main. The favourites and every step of a chain run with a new command tree, so they
+// never see the flags of the command line or of the previous step. Without it, they run in a child process of the
+// executable of the tool.
+func WithCommandTree(newRoot func() *cobra.Command) commandOption {
+	return func(options *commandOptions) {
+		options.newRootCommand = newRoot
+	}
+}
+
+// WithHistory records the last successfully executed commands of the tool in a history file next to the config file.
+// The values of secret flags like '--password' are replaced by placeholders.
+func WithHistory(size int) commandOption {
+	return func(options *commandOptions) {
+		options.historySize = size
+	}
+}
+
 func init() {
+	favListCmd.Flags().StringVar(&favListArgs.sort, "sort", string(toolsconfig.FavouriteOrderName), "Sort order (name, recent, most-used)")
+	_ = favListCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(toolsconfig.FavouriteOrderName), string(toolsconfig.FavouriteOrderRecent), string(toolsconfig.FavouriteOrderMostUsed)}, cobra.ShellCompDirectiveNoFileComp
+	})
 	favCmd.AddCommand(favListCmd)
+	favCmd.AddCommand(favShowCmd)
+	favCmd.AddCommand(favRemoveCmd)
+	favCmd.AddCommand(favRenameCmd)
+	favCmd.AddCommand(favEditCmd)
+	favCmd.AddCommand(favCopyCmd)
+	favChainCmd.Flags().BoolVar(&favChainArgs.continueOnError, "continue-on-error", false, "Run the remaining favourites after a failed favourite")
+	favCmd.AddCommand(favChainCmd)
+	favCmd.AddCommand(favCheckCmd)
+	favExportCmd.Flags().StringVar(&favExportArgs.format, "format", toolsconfig.ConfigFormat, "Format of the export (yaml, json)")
+	favExportCmd.Flags().StringVarP(&favExportArgs.output, "output", "o", "", "Write the export to the file instead of stdout")
+	_ = favExportCmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{toolsconfig.ConfigFormat, "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	favCmd.AddCommand(favExportCmd)
+	favImportCmd.Flags().StringVar(&favImportArgs.strategy, "strategy", string(toolsconfig.ImportSkip), "Handling of existing favourites with the same name (skip, overwrite, rename)")
+	_ = favImportCmd.RegisterFlagCompletionFunc("strategy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(toolsconfig.ImportSkip), string(toolsconfig.ImportOverwrite), string(toolsconfig.ImportRename)}, cobra.ShellCompDirectiveNoFileComp
+	})
+	favCmd.AddCommand(favImportCmd)
+	historyCmd.AddCommand(historyListCmd)
+	historyRerunCmd.Flags().BoolVar(&rootArgs.dryRun, "dry-run", false, "Print the command without running it")
+	historyRerunCmd.Flags().BoolVar(&rootArgs.yes, "yes", false, "Run the command without asking for confirmation")
+	historyCmd.AddCommand(historyRerunCmd)
+	historyCmd.AddCommand(historySaveCmd)
 }
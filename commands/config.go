@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/daolis/toolsconfig"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(cmd.Help())
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var configEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List the environment variables overriding the credentials",
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(listEnvBindings())
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+var configExplainArgs struct {
+	resolve bool
+}
+
+var configExplainCmd = &cobra.Command{
+	Use:   "explain <kind> <name>",
+	Short: "Explain where the values of a credential come from, secrets are masked",
+	Long: `Explain where the values of a credential come from, secrets are masked.
+The kind is the section of the configuration file (servers, azureSubscriptions, generics, databases, kubernetes,
+oauth2 or custom.<type>). Secret references are only resolved with --resolve, which runs the commands of 'cmd:'
+references and reads the secrets of Vault and Key Vault.`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return []string{"servers", "azureSubscriptions", "generics", "databases", "kubernetes", "oauth2"}, cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(explainCredential(args[0], args[1], configExplainArgs.resolve))
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// do not call PersistentPreRun from parent
+	},
+}
+
+func explainCredential(kind, name string, resolve bool) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	explanation, err := cfg.Explain(kind, name, toolsconfig.ExplainResolve(resolve))
+	if err != nil {
+		return err
+	}
+	return PrintExplanation(os.Stdout, explanation)
+}
+
+// PrintExplanation prints the summary of the explanation and a table with the source, environment variable, reference
+// and masked value of every field.
+func PrintExplanation(out io.Writer, explanation *toolsconfig.Explanation) error {
+	_, _ = fmt.Fprintf(out, "\n%s\n\n", explanation)
+	w := tabwriter.NewWriter(out, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "FIELD\tSOURCE\tVARIABLE\tREFERENCE\tVALUE\n")
+	for _, field := range explanation.Fields {
+		value := field.Value
+		if field.Error != "" {
+			value = fmt.Sprintf("%s%s%s", chalk.Red, field.Error, chalk.ResetColor)
+		}
+		_, _ = fmt.Fprintf(w, "%s%s%s\t%s\t%s\t%s\t%s\n", chalk.Yellow, field.Field, chalk.ResetColor, field.Source, field.Variable, field.Reference, value)
+	}
+	return w.Flush()
+}
+
+func listEnvBindings() error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "KIND\tNAME\tFIELD\tVARIABLES\tSET\n")
+	for _, binding := range cfg.EnvBindings() {
+		set := ""
+		if binding.IsSet() {
+			set = fmt.Sprintf("%syes%s", chalk.Yellow, chalk.ResetColor)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", binding.Kind, binding.Name, binding.Field, strings.Join(binding.Variables, ", "), set)
+	}
+	_ = w.Flush()
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configEnvCmd)
+	configExplainCmd.Flags().BoolVar(&configExplainArgs.resolve, "resolve", false, "Resolve the secret references and variables, the values are masked")
+	configCmd.AddCommand(configExplainCmd)
+}
@@ -1,33 +1,429 @@
 package commands
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"runtime"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
 	"github.com/ttacon/chalk"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
+	"github.com/daolis/toolsconfig"
 )
 
-func saveFavourite(tool, name string, args []string) error {
+func saveFavourite(tool string, favourite toolsconfig.Favourite) error {
 	cfg, err := newToolsConfig()
 	if err != nil {
 		return err
 	}
-	return cfg.SaveFavourite(tool, name, args)
+	return cfg.SetFavourite(tool, favourite)
+}
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// stdinIsTerminal returns true if stdin is a character device other than the null device.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	devNull, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, devNull)
+}
+
+// readPassword reads a line from the terminal without echo.
+var readPassword = func() (string, error) {
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	_, _ = fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
+
+// promptPlaceholder reads the value of a placeholder from stdin. Values of placeholders with secret names like
+// '{{password}}', e.g. of redacted history entries, are read without echo.
+func promptPlaceholder(name string) (string, error) {
+	_, _ = fmt.Fprintf(os.Stderr, "%s%s%s: ", chalk.Yellow, name, chalk.ResetColor)
+	if toolsconfig.IsSecretName(name) {
+		value, err := readPassword()
+		if err != nil {
+			return "", fmt.Errorf("no value for placeholder '%s': %w", name, err)
+		}
+		return value, nil
+	}
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no value for placeholder '%s': %w", name, err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
 }
 
-func listFavourites(tool string) error {
+// promptConfirmation asks the question on stderr and returns true if the answer read from stdin is yes.
+func promptConfirmation(question string) (bool, error) {
+	_, _ = fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return false, fmt.Errorf("no answer: %w", err)
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+func listFavourites(tool string, order toolsconfig.FavouriteOrder) error {
 	cfg, err := newToolsConfig()
 	if err != nil {
 		return err
 	}
+	favourites := cfg.GetFavourites(tool)
+	toolsconfig.SortFavourites(favourites, order)
 	fmt.Printf("\nFavourites (execute with '%s --run [NAME])\n", tool)
 	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
-	_, _ = fmt.Fprintf(w, "NAME\tCOMMAND\n")
-	for _, fav := range cfg.GetFavourites(tool) {
-		_, _ = fmt.Fprintf(w, "%s%s%s\t'%s %s'\n", chalk.Yellow, fav.Name, chalk.ResetColor, tool, strings.Join(fav.Args, " "))
+	_, _ = fmt.Fprintf(w, "NAME\tDESCRIPTION\tRUNS\tLAST RUN\tCOMMAND\n")
+	for _, fav := range favourites {
+		var shared string
+		if fav.SharedFile != "" {
+			shared = " (shared)"
+		}
+		_, _ = fmt.Fprintf(w, "%s%s%s%s\t%s\t%d\t%s\t%s\n", chalk.Yellow, fav.Name, chalk.ResetColor, shared, fav.Description,
+			fav.RunCount, FormatTime(fav.LastRun), fav.CommandLine(tool))
 	}
 	_ = w.Flush()
 	return nil
 }
+
+// FormatTime formats the time of favourites and history entries in the local time zone, '-' for the zero time.
+func FormatTime(value time.Time) string {
+	if value.IsZero() {
+		return "-"
+	}
+	return value.Local().Format("2006-01-02 15:04")
+}
+
+// completeFavouriteNames returns the names of the favourites of the tool for shell completion.
+func completeFavouriteNames(cmd *cobra.Command) ([]string, cobra.ShellCompDirective) {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	favourites := cfg.GetFavourites(cmd.Root().Name())
+	favNames := make([]string, len(favourites))
+	for idx, favourite := range favourites {
+		favNames[idx] = favourite.Name
+	}
+	return favNames, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFavouriteArgs completes the first count arguments with the names of the favourites, all arguments if count is negative.
+func completeFavouriteArgs(count int) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if count >= 0 && len(args) >= count {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeFavouriteNames(cmd)
+	}
+}
+
+func showFavourite(tool, name string) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	fav, err := cfg.GetFavourite(tool, name)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Name:\t%s%s%s\n", chalk.Yellow, fav.Name, chalk.ResetColor)
+	_, _ = fmt.Fprintf(w, "Description:\t%s\n", fav.Description)
+	if fav.SharedFile != "" {
+		_, _ = fmt.Fprintf(w, "Shared by:\t%s\n", fav.SharedFile)
+	}
+	_, _ = fmt.Fprintf(w, "Command:\t%s\n", fav.CommandLine(tool))
+	if fav.Confirm {
+		_, _ = fmt.Fprintf(w, "Confirm:\t%t\n", fav.Confirm)
+	}
+	if fav.Positional {
+		_, _ = fmt.Fprintf(w, "Positional:\t%t\n", fav.Positional)
+	}
+	if fav.IsChain() && fav.ContinueOnError {
+		_, _ = fmt.Fprintf(w, "Continue on error:\t%t\n", fav.ContinueOnError)
+	}
+	if placeholders := fav.Placeholders(); len(placeholders) > 0 {
+		_, _ = fmt.Fprintf(w, "Placeholders:\t%s\n", strings.Join(placeholders, ", "))
+	}
+	for _, placeholder := range fav.Placeholders() {
+		if value, ok := fav.Defaults[placeholder]; ok {
+			_, _ = fmt.Fprintf(w, "  %s:\t%s\n", placeholder, value)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Created:\t%s\n", FormatTime(fav.Created))
+	_, _ = fmt.Fprintf(w, "Last run:\t%s\n", FormatTime(fav.LastRun))
+	_, _ = fmt.Fprintf(w, "Runs:\t%d\n", fav.RunCount)
+	return w.Flush()
+}
+
+func removeFavourites(tool string, names []string) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := cfg.GetFavourite(tool, name); err != nil {
+			return fmt.Errorf("favourite '%s' not found", name)
+		}
+	}
+	for _, name := range names {
+		if err := cfg.RemoveFavourite(tool, name); err != nil {
+			return err
+		}
+		log.WithField("name", name).Info("Removed favourite")
+	}
+	return nil
+}
+
+func renameFavourite(tool, name, newName string) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	if err := cfg.RenameFavourite(tool, name, newName); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"name": name, "newName": newName}).Info("Renamed favourite")
+	return nil
+}
+
+// copyFavourite copies the favourite with a new name, the copy starts without runs.
+func copyFavourite(tool, name, newName string) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	fav, err := cfg.GetFavourite(tool, name)
+	if err != nil {
+		return err
+	}
+	if _, err := cfg.GetFavourite(tool, newName); err == nil {
+		return fmt.Errorf("favourite '%s' already exists", newName)
+	}
+	favCopy := toolsconfig.Favourite{
+		Name:            newName,
+		Args:            append([]string{}, fav.Args...),
+		Steps:           append([]toolsconfig.FavouriteStep{}, fav.Steps...),
+		ContinueOnError: fav.ContinueOnError,
+		Positional:      fav.Positional,
+		Defaults:        fav.Defaults,
+		Description:     fav.Description,
+		Confirm:         fav.Confirm,
+	}
+	if err := cfg.SetFavourite(tool, favCopy); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"name": name, "newName": newName}).Info("Copied favourite")
+	return nil
+}
+
+// editFavourite opens the arguments of the favourite as yaml list in the editor of $VISUAL or $EDITOR, so arguments
+// with newlines, quotes or empty arguments are kept.
+func editFavourite(tool, name string) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	fav, err := cfg.GetFavourite(tool, name)
+	if err != nil {
+		return err
+	}
+	if fav.IsChain() {
+		return fmt.Errorf("favourite '%s' is a chain, edit the steps in the configuration file", name)
+	}
+	content, err := yaml.Marshal(fav.Args)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp("", "favourite-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(fmt.Sprintf("# arguments of the favourite '%s', one list item per argument\n%s", name, content))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	editor := strings.Fields(editorCommand())
+	command := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", strings.Join(editor, " "), err)
+	}
+	content, err = os.ReadFile(file.Name())
+	if err != nil {
+		return err
+	}
+	var args []string
+	if err := yaml.Unmarshal(content, &args); err != nil {
+		return fmt.Errorf("favourite '%s' not changed, the arguments are no yaml list: %w", name, err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("favourite '%s' not changed, no arguments left", name)
+	}
+	fav.Args = args
+	if err := cfg.SetFavourite(tool, *fav); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"name": name, "args": strings.Join(args, " ")}).Info("Updated favourite")
+	return nil
+}
+
+// saveFavouriteChain saves a chain of the favourites. The favourites must exist.
+func saveFavouriteChain(tool, name string, favourites []string, continueOnError bool) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	chain := toolsconfig.Favourite{Name: name, Description: rootArgs.description, Confirm: rootArgs.confirm,
+		ContinueOnError: continueOnError}
+	for _, favourite := range favourites {
+		if _, err := cfg.GetFavourite(tool, favourite); err != nil {
+			return fmt.Errorf("favourite '%s' not found", favourite)
+		}
+		chain.Steps = append(chain.Steps, toolsconfig.FavouriteStep{Favourite: favourite})
+	}
+	if _, err := cfg.GetFavourite(tool, name); err == nil {
+		return fmt.Errorf("favourite '%s' already exists", name)
+	}
+	if err := cfg.SetFavourite(tool, chain); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"name": name, "steps": strings.Join(favourites, " -> ")}).Info("Saved favourite chain")
+	return nil
+}
+
+// exportFavourites writes the favourites to the output file or stdout.
+func exportFavourites(root *cobra.Command, names []string, format, output string) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	bundle, err := cfg.ExportFavourites(root.Name(), commandFlags(root), names...)
+	if err != nil {
+		return err
+	}
+	data, err := bundle.Marshal(format)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"file": output, "count": len(bundle.Favourites)}).Info("Exported favourites")
+	return nil
+}
+
+// importFavourites imports the favourites of the file, '-' reads stdin.
+func importFavourites(root *cobra.Command, file string, strategy toolsconfig.ImportStrategy) error {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(stdinReader)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+	bundle, err := toolsconfig.ParseFavouriteBundle(data)
+	if err != nil {
+		return err
+	}
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	results, err := cfg.ImportFavourites(root.Name(), *bundle, strategy, commandFlags(root))
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "NAME\tIMPORTED AS\tSTATUS\n")
+	for _, result := range results {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", result.Name, result.ImportedAs, result.Status)
+	}
+	return w.Flush()
+}
+
+// checkFavourites validates the favourites against the commands of the root, all favourites if no names are given.
+func checkFavourites(root *cobra.Command, names []string) error {
+	tool := root.Name()
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		for _, fav := range cfg.GetFavourites(tool) {
+			names = append(names, fav.Name)
+		}
+	}
+	var stale int
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "NAME\tSTATUS\tERROR\n")
+	for _, name := range names {
+		err := checkFavourite(cfg, root, name)
+		if err == nil {
+			_, _ = fmt.Fprintf(w, "%s\t%sok%s\t\n", name, chalk.Green, chalk.ResetColor)
+			continue
+		}
+		stale++
+		_, _ = fmt.Fprintf(w, "%s\t%sstale%s\t%s\n", name, chalk.Red, chalk.ResetColor, err)
+	}
+	_ = w.Flush()
+	if stale > 0 {
+		return fmt.Errorf("%d of %d favourites do not match the commands of %s", stale, len(names), tool)
+	}
+	return nil
+}
+
+func checkFavourite(cfg toolsconfig.Configuration, root *cobra.Command, name string) error {
+	favourites, err := cfg.FavouriteChain(root.Name(), name)
+	if err != nil {
+		return err
+	}
+	for _, fav := range favourites {
+		if err := validateFavouriteArgs(root, fav.Args); err != nil {
+			if fav.Name != name {
+				return fmt.Errorf("step '%s': %w", fav.Name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func editorCommand() string {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(variable)); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
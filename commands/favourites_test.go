@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/daolis/toolsconfig"
+)
+
+// captureStdout returns the output written to stdout by fn.
+func captureStdout(t *testing.T, fn func()) string {
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() {
+		os.Stdout = stdout
+	}()
+	fn()
+	require.NoError(t, writer.Close())
+	output, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(output)
+}
+
+func TestManageFavourites(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`favourites:
+  savetool:
+    prod:
+      name: prod
+      args: [deploy, --env, "{{env}}", "line one\nline two", ""]
+      description: Deploy to production
+      defaults:
+        env: prod
+    release:
+      name: release
+      steps:
+        - favourite: prod
+        - args: [deploy, app]
+      continueOnError: true
+`), 0600))
+	toolsconfig.ConfigFileLocation(dir, "config.yaml")
+
+	var parsed string
+	execute := func(args ...string) {
+		rootCmd := newSaveTestCommand(&parsed)
+		rootCmd.SetArgs(args)
+		require.NoError(t, rootCmd.Execute())
+	}
+	favourite := func(name string) *toolsconfig.Favourite {
+		cfg, err := newToolsConfig()
+		require.NoError(t, err)
+		fav, err := cfg.GetFavourite("savetool", name)
+		if err != nil {
+			return nil
+		}
+		return fav
+	}
+
+	t.Run("show", func(t *testing.T) {
+		output := captureStdout(t, func() { execute("fav", "show", "prod") })
+		require.Contains(t, output, "Deploy to production")
+		require.Contains(t, output, "Placeholders:")
+		require.Contains(t, output, "env:")
+		output = captureStdout(t, func() { execute("fav", "show", "release") })
+		require.Contains(t, output, "prod -> 'deploy app'")
+		require.Contains(t, output, "Continue on error:")
+		require.Error(t, showFavourite("savetool", "unknown"))
+	})
+
+	t.Run("cp", func(t *testing.T) {
+		execute("fav", "cp", "prod", "prod-copy")
+		copied := favourite("prod-copy")
+		require.NotNil(t, copied)
+		require.Equal(t, favourite("prod").Args, copied.Args)
+		require.Equal(t, map[string]string{"env": "prod"}, copied.Defaults)
+		require.Zero(t, copied.RunCount)
+
+		execute("fav", "cp", "release", "release-copy")
+		copied = favourite("release-copy")
+		require.NotNil(t, copied)
+		require.Equal(t, favourite("release").Steps, copied.Steps)
+		require.True(t, copied.ContinueOnError)
+		require.Error(t, copyFavourite("savetool", "prod", "release"), "favourite exists")
+		require.Error(t, copyFavourite("savetool", "unknown", "other"))
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		execute("fav", "rename", "prod-copy", "renamed")
+		require.Nil(t, favourite("prod-copy"))
+		require.NotNil(t, favourite("renamed"))
+		require.Error(t, renameFavourite("savetool", "renamed", "prod"), "favourite exists")
+	})
+
+	t.Run("rm", func(t *testing.T) {
+		execute("fav", "rm", "renamed", "release-copy")
+		require.Nil(t, favourite("renamed"))
+		require.Nil(t, favourite("release-copy"))
+		require.Error(t, removeFavourites("savetool", []string{"prod", "unknown"}))
+		require.NotNil(t, favourite("prod"), "nothing is removed if a favourite is missing")
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", "true")
+		execute("fav", "edit", "prod")
+		require.Equal(t, []string{"deploy", "--env", "{{env}}", "line one\nline two", ""}, favourite("prod").Args)
+
+		edited := filepath.Join(dir, "edited.yaml")
+		require.NoError(t, os.WriteFile(edited, []byte("- deploy\n- --env\n- \"\"\n- |-\n  first\n  second\n"), 0600))
+		t.Setenv("EDITOR", "cp "+edited)
+		execute("fav", "edit", "prod")
+		require.Equal(t, []string{"deploy", "--env", "", "first\nsecond"}, favourite("prod").Args)
+
+		require.NoError(t, os.WriteFile(edited, []byte("# all removed\n"), 0600))
+		require.Error(t, editFavourite("savetool", "prod"))
+		require.NoError(t, os.WriteFile(edited, []byte("deploy: [\n"), 0600))
+		require.Error(t, editFavourite("savetool", "prod"))
+		require.Equal(t, []string{"deploy", "--env", "", "first\nsecond"}, favourite("prod").Args)
+		require.Error(t, editFavourite("savetool", "release"), "chains are edited in the configuration file")
+	})
+}
+
+func TestPromptPlaceholder(t *testing.T) {
+	defer func(read func() (string, error)) { readPassword = read }(readPassword)
+	var hidden []string
+	readPassword = func() (string, error) {
+		hidden = append(hidden, "read")
+		return "s3cret", nil
+	}
+	stdinReader = bufio.NewReader(strings.NewReader("prod\n"))
+	defer func() { stdinReader = bufio.NewReader(os.Stdin) }()
+
+	value, err := promptPlaceholder("password")
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", value)
+	value, err = promptPlaceholder("env")
+	require.NoError(t, err)
+	require.Equal(t, "prod", value)
+	require.Len(t, hidden, 1, "only secret placeholders are read without echo")
+}
@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+	"gopkg.in/yaml.v3"
+
+	"github.com/daolis/toolsconfig"
+)
+
+// historySize is the number of commands kept in the history per tool, set with WithHistory. 0 disables the history.
+var historySize int
+
+// ranFavourite is set if the root command ran a favourite, the commands of the favourite are recorded instead.
+var ranFavourite bool
+
+type historyEntry struct {
+	Args []string  `yaml:"args,flow"`
+	Time time.Time `yaml:"time"`
+}
+
+// commandHistory is the content of the history file, the entries of each tool are ordered from old to new.
+type commandHistory struct {
+	Tools map[string][]historyEntry `yaml:"tools"`
+}
+
+// historyFile returns the path of the history file next to the config file, e.g. 'config.history.yaml'.
+func historyFile() (string, error) {
+	configFile, err := toolsconfig.ConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+	extension := filepath.Ext(configFile)
+	return strings.TrimSuffix(configFile, extension) + ".history" + extension, nil
+}
+
+func readHistory(file string) (*commandHistory, error) {
+	history := &commandHistory{}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, history); err != nil {
+		return nil, fmt.Errorf("invalid history file '%s': %w", file, err)
+	}
+	return history, nil
+}
+
+func writeHistory(file string, history *commandHistory) error {
+	data, err := yaml.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return toolsconfig.WriteFileAtomic(file, data)
+}
+
+// skipHistory returns true for commands which are not recorded: the commands managing favourites, the config or the
+// history and the help and completion commands.
+func skipHistory(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if cmd == favCmd || cmd == configCmd || cmd == historyCmd {
+			return true
+		}
+		switch cmd.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return true
+		}
+	}
+	return false
+}
+
+// recordHistory adds the successfully executed command to the history, the values of secret flags are replaced by
+// placeholders, see redactedArgs.
+func recordHistory(cmd *cobra.Command, args []string) error {
+	if historySize <= 0 || skipHistory(cmd) {
+		return nil
+	}
+	file, err := historyFile()
+	if err != nil {
+		return err
+	}
+	history, err := readHistory(file)
+	if err != nil {
+		return err
+	}
+	tool := cmd.Root().Name()
+	if history.Tools == nil {
+		history.Tools = map[string][]historyEntry{}
+	}
+	entries := append(history.Tools[tool], historyEntry{Args: redactedArgs(cmd, args), Time: time.Now()})
+	if len(entries) > historySize {
+		entries = entries[len(entries)-historySize:]
+	}
+	history.Tools[tool] = entries
+	return writeHistory(file, history)
+}
+
+// historyEntryArgs returns the args of the history entry with the number, 1 is the last command.
+func historyEntryArgs(tool, number string) ([]string, error) {
+	index, err := strconv.Atoi(number)
+	if err != nil {
+		return nil, fmt.Errorf("invalid history number '%s'", number)
+	}
+	file, err := historyFile()
+	if err != nil {
+		return nil, err
+	}
+	history, err := readHistory(file)
+	if err != nil {
+		return nil, err
+	}
+	entries := history.Tools[tool]
+	if index < 1 || index > len(entries) {
+		return nil, fmt.Errorf("history entry %d not found (%d entries)", index, len(entries))
+	}
+	return entries[len(entries)-index].Args, nil
+}
+
+func listHistory(tool string) error {
+	file, err := historyFile()
+	if err != nil {
+		return err
+	}
+	history, err := readHistory(file)
+	if err != nil {
+		return err
+	}
+	entries := history.Tools[tool]
+	fmt.Printf("\nHistory (run again with '%s history rerun [NUMBER]', save with '%s history save [NUMBER] [NAME]')\n", tool, tool)
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "NUMBER\tTIME\tCOMMAND\n")
+	for idx := len(entries) - 1; idx >= 0; idx-- {
+		_, _ = fmt.Fprintf(w, "%s%d%s\t%s\t'%s %s'\n", chalk.Yellow, len(entries)-idx, chalk.ResetColor,
+			FormatTime(entries[idx].Time), tool, strings.Join(entries[idx].Args, " "))
+	}
+	return w.Flush()
+}
+
+// rerunHistory runs the command of the history entry again like a favourite, redacted secrets are prompted.
+func rerunHistory(root *cobra.Command, number string, values []string) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	args, err := historyEntryArgs(root.Name(), number)
+	if err != nil {
+		return err
+	}
+	favourite := toolsconfig.Favourite{Name: "history " + number, Args: args}
+	return runFavourites(root, cfg, favourite, []toolsconfig.Favourite{favourite}, values, false)
+}
+
+// saveHistory saves the command of the history entry as favourite.
+func saveHistory(tool, number, name string) error {
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	args, err := historyEntryArgs(tool, number)
+	if err != nil {
+		return err
+	}
+	if _, err := cfg.GetFavourite(tool, name); err == nil {
+		return fmt.Errorf("favourite '%s' already exists", name)
+	}
+	favourite := toolsconfig.Favourite{Name: name, Args: args, Defaults: rootArgs.defaults, Description: rootArgs.description,
+		Confirm: rootArgs.confirm, Positional: rootArgs.positional}
+	if err := cfg.SetFavourite(tool, favourite); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"name": name, "args": strings.Join(args, " ")}).Info("Saved command as favourite")
+	return nil
+}
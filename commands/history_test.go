@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/daolis/toolsconfig"
+)
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`favourites:
+  savetool:
+    prod:
+      name: prod
+      args: [deploy, --replicas, "3"]
+`), 0600))
+	toolsconfig.ConfigFileLocation(dir, "config.yaml")
+	t.Cleanup(func() {
+		historySize = 0
+	})
+
+	var parsed string
+	execute := func(args ...string) {
+		rootCmd := newSaveTestCommand(&parsed, WithHistory(3))
+		rootCmd.SetArgs(args)
+		require.NoError(t, rootCmd.Execute())
+	}
+	execute("deploy", "one")
+	execute("deploy", "two")
+	execute("fav", "list")
+	execute("--run", "prod")
+	execute("deploy", "-e", "prod", "--password", "s3cret", "web")
+
+	history, err := readHistory(filepath.Join(dir, "config.history.yaml"))
+	require.NoError(t, err)
+	var commands [][]string
+	for _, entry := range history.Tools["savetool"] {
+		commands = append(commands, entry.Args)
+	}
+	require.Equal(t, [][]string{
+		{"deploy", "two"},
+		{"deploy", "--replicas=3"},
+		{"deploy", "--env=prod", "--password={{password}}", "web"},
+	}, commands)
+
+	execute("history", "list")
+	execute("history", "save", "1", "fromhistory")
+	cfg, err := newToolsConfig()
+	require.NoError(t, err)
+	favourite, err := cfg.GetFavourite("savetool", "fromhistory")
+	require.NoError(t, err)
+	require.Equal(t, []string{"deploy", "--env=prod", "--password={{password}}", "web"}, favourite.Args)
+
+	parsed = ""
+	execute("history", "rerun", "2")
+	require.Contains(t, parsed, "--replicas=3")
+
+	rootCmd := newSaveTestCommand(&parsed, WithHistory(3))
+	// the rerun is the last command now
+	require.Error(t, rerunHistory(rootCmd, "2", nil), "the secret has no value")
+	require.Error(t, rerunHistory(rootCmd, "4", nil))
+	require.Error(t, saveHistory("savetool", "1", "prod"), "favourite exists")
+}
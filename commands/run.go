@@ -0,0 +1,306 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/ttacon/chalk"
+
+	"github.com/daolis/toolsconfig"
+)
+
+type stepStatus string
+
+const (
+	stepSucceeded stepStatus = "ok"
+	stepFailed    stepStatus = "failed"
+	stepSkipped   stepStatus = "skipped"
+)
+
+type stepResult struct {
+	name     string
+	args     []string
+	status   stepStatus
+	duration time.Duration
+	err      error
+}
+
+// runningFavourites are the favourites currently running, to detect favourites running themselves.
+var runningFavourites = map[string]bool{}
+
+// RunFavourite runs the favourite or the steps of a favourite chain with the root command of the command, so it can be
+// called from any sub command. The values are the values of the placeholders, see toolsconfig.PlaceholderValues.
+// The args of all steps are validated against the commands and flags of the root command before the first step runs.
+func RunFavourite(cmd *cobra.Command, name string, values ...string) error {
+	root := cmd.Root()
+	tool := root.Name()
+	if runningFavourites[name] {
+		return fmt.Errorf("favourite '%s' runs itself", name)
+	}
+	if rootArgs.saveName != "" {
+		return fmt.Errorf("--save can not be used with --run, copy the favourite with '%s fav cp %s %s'", tool, name, rootArgs.saveName)
+	}
+	runningFavourites[name] = true
+	defer delete(runningFavourites, name)
+
+	cfg, err := newToolsConfig()
+	if err != nil {
+		return err
+	}
+	favourite, err := cfg.GetFavourite(tool, name)
+	if err != nil {
+		return err
+	}
+	favourites, err := cfg.FavouriteChain(tool, name)
+	if err != nil {
+		return err
+	}
+	return runFavourites(root, cfg, *favourite, favourites, values, true)
+}
+
+// runFavourites runs the favourites of the favourite, see RunFavourite. The run of the favourite is recorded if
+// record is set.
+func runFavourites(root *cobra.Command, cfg toolsconfig.Configuration, favourite toolsconfig.Favourite,
+	favourites []toolsconfig.Favourite, values []string, record bool) error {
+	tool := root.Name()
+	name := favourite.Name
+	dryRun, confirmed := rootArgs.dryRun, rootArgs.yes
+	var err error
+
+	// expand all steps before running the first, so missing values are reported early and prompted only once
+	placeholderValues := toolsconfig.PlaceholderValues(values, favourites...)
+	var prompt toolsconfig.PlaceholderPrompt
+	if stdinIsTerminal() {
+		prompt = func(placeholder string) (string, error) {
+			value, err := promptPlaceholder(placeholder)
+			placeholderValues[placeholder] = value
+			return value, err
+		}
+	}
+	stepArgs := make([][]string, len(favourites))
+	for idx, stepFavourite := range favourites {
+		stepArgs[idx], err = stepFavourite.Expand(placeholderValues, prompt)
+		if err != nil {
+			return err
+		}
+	}
+	for idx, stepFavourite := range favourites {
+		if err := validateFavouriteArgs(root, stepArgs[idx]); err != nil {
+			return staleFavouriteError(tool, stepFavourite.Name, err)
+		}
+	}
+	reason, err := cfg.RequiresConfirmation(favourite, nil)
+	for idx := 0; idx < len(favourites) && reason == "" && err == nil; idx++ {
+		reason, err = cfg.RequiresConfirmation(favourites[idx], stepArgs[idx])
+	}
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		printCommands(os.Stdout, tool, stepArgs)
+		if reason != "" {
+			fmt.Printf("Requires confirmation: %s\n", reason)
+		}
+		return nil
+	}
+	if reason != "" && !confirmed {
+		if err := confirmFavourite(tool, name, reason, stepArgs); err != nil {
+			return err
+		}
+	}
+	if record {
+		if err := cfg.RecordFavouriteRun(tool, name); err != nil {
+			log.WithError(err).Warn("Could not record the run of the favourite")
+		}
+	}
+
+	if !favourite.IsChain() {
+		log.WithFields(log.Fields{"name": name, "args": strings.Join(stepArgs[0], " ")}).Info("Running saved favourite")
+		return executeFavourite(stepArgs[0])
+	}
+
+	results := make([]stepResult, len(favourites))
+	var failed bool
+	for idx, stepFavourite := range favourites {
+		results[idx] = stepResult{name: stepFavourite.Name, args: stepArgs[idx], status: stepSkipped}
+		if failed && !favourite.ContinueOnError {
+			continue
+		}
+		log.WithFields(log.Fields{"chain": name, "step": fmt.Sprintf("%d/%d", idx+1, len(favourites)), "name": stepFavourite.Name,
+			"args": strings.Join(stepArgs[idx], " ")}).Info("Running step of favourite chain")
+		start := time.Now()
+		err := executeFavourite(stepArgs[idx])
+		results[idx].duration = time.Since(start)
+		results[idx].status = stepSucceeded
+		if err != nil {
+			results[idx].status = stepFailed
+			results[idx].err = err
+			failed = true
+		}
+	}
+	printStepResults(root.Name(), results)
+	if failed {
+		return fmt.Errorf("favourite chain '%s' failed", name)
+	}
+	return nil
+}
+
+// newRootCommand creates the root command of the tool with all sub commands, set with WithCommandTree.
+var newRootCommand func() *cobra.Command
+
+// executeFavourite runs the args of a favourite or a step of a chain with a fresh command tree, so they never see the
+// flags parsed before, e.g. '--run' or the flags of the previous step: the root command created by the function set
+// with WithCommandTree, otherwise the executable of the tool in a child process.
+func executeFavourite(args []string) error {
+	if newRootCommand != nil {
+		root := newRootCommand()
+		root.SetArgs(args)
+		return root.Execute()
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	command := exec.Command(executable, args...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return command.Run()
+}
+
+// confirmFavourite prints the commands of the favourite and asks for confirmation. Returns an error if not confirmed.
+func confirmFavourite(tool, name, reason string, stepArgs [][]string) error {
+	if !stdinIsTerminal() {
+		return fmt.Errorf("running favourite '%s' requires confirmation (%s), confirm with --yes", name, reason)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "%s%s%s\n", chalk.Yellow, reason, chalk.ResetColor)
+	printCommands(os.Stderr, tool, stepArgs)
+	confirmed, err := promptConfirmation(fmt.Sprintf("Run favourite '%s'?", name))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("running favourite '%s' not confirmed", name)
+	}
+	return nil
+}
+
+// printCommands prints the command lines of the args, quoting args with spaces.
+func printCommands(w io.Writer, tool string, stepArgs [][]string) {
+	for _, args := range stepArgs {
+		quoted := make([]string, len(args))
+		for idx, arg := range args {
+			quoted[idx] = arg
+			if arg == "" || strings.ContainsAny(arg, " \t\n'\"") {
+				quoted[idx] = strconv.Quote(arg)
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s %s\n", tool, strings.Join(quoted, " "))
+	}
+}
+
+func staleFavouriteError(tool, name string, err error) error {
+	return fmt.Errorf("favourite '%s' does not match the commands of %s (see '%s fav check'): %w", name, tool, tool, err)
+}
+
+// commandFlagSet returns the local, persistent and inherited flags of the command.
+func commandFlagSet(cmd *cobra.Command) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	flags.AddFlagSet(cmd.Flags())
+	flags.AddFlagSet(cmd.PersistentFlags())
+	flags.AddFlagSet(cmd.InheritedFlags())
+	return flags
+}
+
+// commandFlags returns the flags of the sub command of the root run by the args, see toolsconfig.RedactSecretArgs.
+func commandFlags(root *cobra.Command) toolsconfig.CommandFlags {
+	return func(args []string) *pflag.FlagSet {
+		cmd, _, err := root.Find(args)
+		if err != nil {
+			return nil
+		}
+		return commandFlagSet(cmd)
+	}
+}
+
+// validateFavouriteArgs returns an error if the sub commands or flags of the args do not exist in the command tree of
+// the root, the positional args are invalid or the args run another favourite.
+func validateFavouriteArgs(root *cobra.Command, args []string) error {
+	cmd, rest, err := root.Find(args)
+	if err != nil {
+		return err
+	}
+	flags := commandFlagSet(cmd)
+	var positional []string
+	for idx := 0; idx < len(rest); idx++ {
+		arg := rest[idx]
+		switch {
+		case arg == "--":
+			positional = append(positional, rest[idx+1:]...)
+			idx = len(rest)
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			flag := flags.Lookup(name)
+			if flag == nil {
+				return fmt.Errorf("unknown flag '--%s' for '%s'", name, cmd.CommandPath())
+			}
+			if favouriteFlags[flag.Name] {
+				return fmt.Errorf("flag '--%s' can not be used in favourites", flag.Name)
+			}
+			if !hasValue && flag.NoOptDefVal == "" {
+				idx++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			shorthands := arg[1:]
+			for pos := 0; pos < len(shorthands); pos++ {
+				flag := flags.ShorthandLookup(shorthands[pos : pos+1])
+				if flag == nil {
+					return fmt.Errorf("unknown shorthand flag '%c' in '%s' for '%s'", shorthands[pos], arg, cmd.CommandPath())
+				}
+				if favouriteFlags[flag.Name] {
+					return fmt.Errorf("flag '--%s' can not be used in favourites", flag.Name)
+				}
+				if flag.NoOptDefVal == "" {
+					// the rest of the arg or the next arg is the value
+					if pos == len(shorthands)-1 {
+						idx++
+					}
+					break
+				}
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 0 && cmd.HasSubCommands() && !cmd.Runnable() {
+		return fmt.Errorf("unknown command '%s' for '%s'", positional[0], cmd.CommandPath())
+	}
+	return cmd.ValidateArgs(positional)
+}
+
+func printStepResults(tool string, results []stepResult) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 4, ' ', 0)
+	_, _ = fmt.Fprintf(w, "STEP\tNAME\tSTATUS\tDURATION\tCOMMAND\n")
+	for idx, result := range results {
+		color := chalk.Green
+		switch result.status {
+		case stepFailed:
+			color = chalk.Red
+		case stepSkipped:
+			color = chalk.Yellow
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s%s%s\t%s\t'%s %s'\n", idx+1, result.name, color, result.status, chalk.ResetColor,
+			result.duration.Round(time.Millisecond), tool, strings.Join(result.args, " "))
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daolis/toolsconfig"
+)
+
+func TestValidateFavouriteArgs(t *testing.T) {
+	var parsed string
+	rootCmd := newSaveTestCommand(&parsed)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "sub command", args: []string{"deploy", "app"}},
+		{name: "long flags", args: []string{"deploy", "--env", "prod", "--replicas=3", "--force"}},
+		{name: "short flags", args: []string{"deploy", "-fv", "-eprod", "-n", "3", "-ll"}},
+		{name: "persistent flag before command", args: []string{"--verbose", "deploy"}},
+		{name: "positional args", args: []string{"deploy", "one", "--", "-two"}},
+		{name: "root flags", args: []string{"-v"}},
+		{name: "unknown command", args: []string{"deploi", "app"}, wantErr: `unknown command "deploi" for "savetool"`},
+		{name: "unknown flag", args: []string{"deploy", "--environment", "prod"}, wantErr: "unknown flag '--environment' for 'savetool deploy'"},
+		{name: "unknown shorthand", args: []string{"deploy", "-fx"}, wantErr: "unknown shorthand flag 'x' in '-fx' for 'savetool deploy'"},
+		{name: "flag of other command", args: []string{"--env", "prod"}, wantErr: "unknown flag '--env' for 'savetool'"},
+		{name: "run", args: []string{"--run", "other"}, wantErr: "flag '--run' can not be used in favourites"},
+		{name: "save", args: []string{"deploy", "--save=other"}, wantErr: "flag '--save' can not be used in favourites"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFavouriteArgs(rootCmd, tt.args)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRunFavourite(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`favourites:
+  savetool:
+    prod:
+      name: prod
+      args: [deploy, -e, prod, "{{app}}"]
+    verbose:
+      name: verbose
+      args: [-v]
+    stale:
+      name: stale
+      args: [deploy, --environment, prod]
+    loop:
+      name: loop
+      args: [--run, loop]
+    production:
+      name: production
+      args: [deploy, --env, prod]
+    confirmed:
+      name: confirmed
+      args: [deploy, web]
+      confirm: true
+    columns:
+      name: columns
+      args: [deploy, "{print $1}", "{{app}}"]
+    positional:
+      name: positional
+      args: [deploy, "$1", "{{app}}", "$$2"]
+      positional: true
+    release:
+      name: release
+      steps:
+        - favourite: prod
+        - favourite: stale
+`), 0600))
+	toolsconfig.ConfigFileLocation(dir, "config.yaml")
+
+	var parsed string
+	rootCmd := newSaveTestCommand(&parsed)
+	rootCmd.SetArgs([]string{"--run", "prod", "app=web"})
+	require.NoError(t, rootCmd.Execute())
+	require.Contains(t, parsed, "env=prod")
+	require.Contains(t, parsed, "args=web")
+
+	rootCmd = newSaveTestCommand(&parsed)
+	rootCmd.SetArgs([]string{"--run", "columns", "app=web"})
+	require.NoError(t, rootCmd.Execute())
+	require.Contains(t, parsed, "args={print $1}|web")
+	rootCmd = newSaveTestCommand(&parsed)
+	rootCmd.SetArgs([]string{"--run", "positional", "key=value", "app=web"})
+	require.NoError(t, rootCmd.Execute())
+	require.Contains(t, parsed, "args=key=value|web|$2")
+
+	// the root runs without running the favourite again
+	rootCmd = newSaveTestCommand(&parsed)
+	rootCmd.SetArgs([]string{"--run", "verbose"})
+	require.NoError(t, rootCmd.Execute())
+
+	cfg, err := newToolsConfig()
+	require.NoError(t, err)
+	favourite, err := cfg.GetFavourite("savetool", "verbose")
+	require.NoError(t, err)
+	require.Equal(t, 1, favourite.RunCount)
+
+	parsed = ""
+	err = RunFavourite(rootCmd, "confirmed")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "confirm with --yes")
+	err = RunFavourite(rootCmd, "production")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "'--env prod' matches")
+	rootCmd = newSaveTestCommand(&parsed)
+	rootCmd.SetArgs([]string{"--run", "production", "--dry-run"})
+	require.NoError(t, rootCmd.Execute())
+	require.Empty(t, parsed)
+	rootCmd = newSaveTestCommand(&parsed)
+	rootCmd.SetArgs([]string{"--run", "confirmed", "--yes"})
+	require.NoError(t, rootCmd.Execute())
+	require.Contains(t, parsed, "args=web")
+
+	parsed = ""
+	rootArgs.saveName = "copy"
+	err = RunFavourite(rootCmd, "prod", "app=web")
+	rootArgs.saveName = ""
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--save can not be used with --run")
+	require.Empty(t, parsed)
+
+	err = RunFavourite(rootCmd, "stale")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "favourite 'stale' does not match the commands of savetool")
+	require.Error(t, RunFavourite(rootCmd, "loop"))
+	err = RunFavourite(rootCmd, "release", "app=web")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "favourite 'stale'")
+	require.Empty(t, parsed, "no step runs if a step is stale")
+}
+
+func TestRunFavouriteChainFreshFlags(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`favourites:
+  savetool:
+    chain:
+      name: chain
+      steps:
+        - args: [deploy, --set, a=1, --tag, x, --label, l1, -f]
+        - args: [deploy, --set, b=2, --tag, y]
+`), 0600))
+	toolsconfig.ConfigFileLocation(dir, "config.yaml")
+
+	var parsed string
+	rootCmd := newSaveTestCommand(&parsed)
+	rootCmd.SetArgs([]string{"--run", "chain"})
+	require.NoError(t, rootCmd.Execute())
+	require.Contains(t, parsed, "--set=b=2 ")
+	require.Contains(t, parsed, "--tag=y ")
+	require.NotContains(t, parsed, "--label")
+	require.Contains(t, parsed, "--force=false")
+}
+
+func TestExecuteFavouriteChildProcess(t *testing.T) {
+	defer func(newRoot func() *cobra.Command) { newRootCommand = newRoot }(newRootCommand)
+	newRootCommand = nil
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+	// discard the usage printed by the child
+	os.Stderr = nil
+	// the executable is the test binary
+	output := captureStdout(t, func() {
+		require.NoError(t, executeFavourite([]string{"-test.run=^$"}))
+	})
+	require.Contains(t, output, "PASS")
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(executeFavourite([]string{"-test.unknown"}), &exitErr))
+}
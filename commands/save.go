@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/daolis/toolsconfig"
+)
+
+// favouriteFlags are the flags of the favourites, they are not part of saved commands.
+var favouriteFlags = map[string]bool{"save": true, "description": true, "default": true, "confirm": true, "positional": true,
+	"run": true}
+
+// savedArgs returns the arguments to run the parsed command again: the path of the sub command, the changed flags with
+// their long names as '--name=value' and the positional args, separated by '--' if an arg looks like a flag.
+func savedArgs(cmd *cobra.Command, args []string) []string {
+	return commandArgs(cmd, args, false)
+}
+
+// redactedArgs returns the saved args of the parsed command, see savedArgs, with the placeholders of the flag names
+// instead of the values of secret flags like '--password={{password}}', see toolsconfig.IsSecretFlag.
+func redactedArgs(cmd *cobra.Command, args []string) []string {
+	return commandArgs(cmd, args, true)
+}
+
+func commandArgs(cmd *cobra.Command, args []string, redact bool) []string {
+	path := strings.Fields(cmd.CommandPath())
+	saved := append([]string{}, path[1:]...)
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Changed || favouriteFlags[flag.Name] {
+			return
+		}
+		if redact && toolsconfig.IsSecretFlag(flag) {
+			saved = append(saved, "--"+flag.Name+"={{"+flag.Name+"}}")
+			return
+		}
+		saved = append(saved, flagArgs(flag)...)
+	})
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			saved = append(saved, "--")
+			break
+		}
+	}
+	return append(saved, args...)
+}
+
+// flagArgs returns the arguments setting the value of the flag.
+func flagArgs(flag *pflag.Flag) []string {
+	prefix := "--" + flag.Name
+	if flag.Value.Type() == "bool" {
+		if flag.Value.String() == "true" {
+			return []string{prefix}
+		}
+		return []string{prefix + "=false"}
+	}
+	if value, ok := flag.Value.(pflag.SliceValue); ok {
+		var result []string
+		for _, item := range value.GetSlice() {
+			if strings.HasSuffix(flag.Value.Type(), "Slice") {
+				// slice flags split the value at commas
+				item = csvQuote(item)
+			}
+			result = append(result, prefix+"="+item)
+		}
+		return result
+	}
+	value := flag.Value.String()
+	if strings.HasPrefix(flag.Value.Type(), "stringTo") {
+		value = sortedMapValue(value)
+	}
+	return []string{prefix + "=" + value}
+}
+
+// sortedMapValue returns the entries of a map flag value formatted as '[key=value,..]' sorted by key, the value is
+// formatted in the random order of the map.
+func sortedMapValue(value string) string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	entries, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return value
+	}
+	sort.Strings(entries)
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	_ = writer.Write(entries)
+	writer.Flush()
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// csvQuote quotes the value if it contains commas or quotes.
+func csvQuote(value string) string {
+	if !strings.ContainsAny(value, ",\"") {
+		return value
+	}
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	_ = writer.Write([]string{value})
+	writer.Flush()
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+
+	"github.com/daolis/toolsconfig"
+)
+
+// newSaveTestCommand returns a root command with a deploy sub command, which writes its parsed flags and args to
+// parsed.
+func newSaveTestCommand(parsed *string, opts ...commandOption) *cobra.Command {
+	deployCmd := &cobra.Command{
+		Use:  "deploy",
+		Args: cobra.ArbitraryArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var values []string
+			cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+				if !favouriteFlags[flag.Name] {
+					values = append(values, flagArgs(flag)...)
+				}
+			})
+			*parsed = strings.Join(values, " ") + " args=" + strings.Join(args, "|")
+		},
+	}
+	deployCmd.Flags().StringP("env", "e", "dev", "")
+	deployCmd.Flags().IntP("replicas", "n", 1, "")
+	deployCmd.Flags().BoolP("force", "f", false, "")
+	deployCmd.Flags().Bool("wait", true, "")
+	deployCmd.Flags().StringSliceP("tag", "t", nil, "")
+	deployCmd.Flags().StringArray("label", nil, "")
+	deployCmd.Flags().StringToString("set", nil, "")
+	deployCmd.Flags().String("password", "", "")
+	deployCmd.AddCommand(&cobra.Command{Use: "app", Run: deployCmd.Run})
+
+	rootCmd := &cobra.Command{Use: "savetool"}
+	newRoot := func() *cobra.Command {
+		return newSaveTestCommand(parsed, opts...)
+	}
+	AddToRootCommand(rootCmd, append([]commandOption{WithCommandTree(newRoot)}, opts...)...)
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "")
+	rootCmd.PersistentFlags().CountP("level", "l", "")
+	rootCmd.AddCommand(deployCmd)
+	return rootCmd
+}
+
+func TestSavedArgs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("favourites: {}\n"), 0600))
+	toolsconfig.ConfigFileLocation(dir, "config.yaml")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "save after command", args: []string{"deploy", "--save", "fav"}, want: []string{"deploy"}},
+		{name: "save with equals", args: []string{"deploy", "--save=fav", "app"}, want: []string{"deploy", "app"}},
+		{name: "save before command", args: []string{"--save", "fav", "deploy", "-e", "prod", "-n", "3"}, want: []string{"deploy", "--env=prod", "--replicas=3"}},
+		{name: "long flags", args: []string{"deploy", "--env", "prod", "--replicas=3", "--save", "fav"}, want: []string{"deploy", "--env=prod", "--replicas=3"}},
+		{name: "combined short flags", args: []string{"deploy", "-fv", "-eprod", "--save", "fav"}, want: []string{"deploy", "--env=prod", "--force", "--verbose"}},
+		{name: "bool values", args: []string{"deploy", "--force=true", "--wait=false", "--save", "fav"}, want: []string{"deploy", "--force", "--wait=false"}},
+		{name: "count", args: []string{"deploy", "-ll", "--level", "--save", "fav"}, want: []string{"deploy", "--level=3"}},
+		{name: "persistent flag before command", args: []string{"-v", "deploy", "app", "--save", "fav"}, want: []string{"deploy", "app", "--verbose"}},
+		{name: "slice", args: []string{"deploy", "-t", "a,b", "--tag", `"c,d"`, "--save", "fav"}, want: []string{"deploy", "--tag=a", "--tag=b", `--tag="c,d"`}},
+		{name: "array", args: []string{"deploy", "--label", "x,y", "--label=z", "--save", "fav"}, want: []string{"deploy", "--label=x,y", "--label=z"}},
+		{name: "map", args: []string{"deploy", "--set", "a=1", "--set=b=2", "--save", "fav"}, want: []string{"deploy", "--set=a=1,b=2"}},
+		{name: "positional args", args: []string{"deploy", "one", "--save", "fav", "two"}, want: []string{"deploy", "one", "two"}},
+		{name: "positional args like flags", args: []string{"deploy", "--save", "fav", "--", "-file", "two"}, want: []string{"deploy", "--", "-file", "two"}},
+		{name: "value like flag", args: []string{"deploy", "--env", "-x", "--save", "fav"}, want: []string{"deploy", "--env=-x"}},
+		{name: "favourite flags", args: []string{"deploy", "--description", "Deploy", "--default=env=dev", "--env", "{{env}}", "--save", "fav"}, want: []string{"deploy", "--env={{env}}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parsed, reparsed string
+			rootCmd := newSaveTestCommand(&parsed)
+			rootCmd.SetArgs(tt.args)
+			require.NoError(t, rootCmd.Execute())
+
+			cfg, err := newToolsConfig()
+			require.NoError(t, err)
+			favourite, err := cfg.GetFavourite("savetool", "fav")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, favourite.Args)
+
+			// the saved args must be parsed to the same values
+			rootCmd = newSaveTestCommand(&reparsed)
+			rootCmd.SetArgs(favourite.Args)
+			require.NoError(t, rootCmd.Execute())
+			require.Equal(t, parsed, reparsed)
+		})
+	}
+}
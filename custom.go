@@ -0,0 +1,213 @@
+package toolsconfig
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+type customCredentialType struct {
+	typ   reflect.Type
+	valid func(value interface{}) bool
+}
+
+var (
+	customCredentialTypesMutex sync.RWMutex
+	customCredentialTypes      = map[string]customCredentialType{}
+)
+
+// RegisterCustomCredential registers a custom credential type stored in the 'custom' section of the configuration.
+// The prototype is a value of a struct with yaml tags, valid returns whether a value of the struct (passed as value,
+// not as pointer) contains everything the tool needs. Secret references like 'env:MY_VAR' are only resolved in fields
+// tagged with 'secret:"true"'. Usually called in an init function of the tool.
+// Panics if the prototype is not a struct or the type name is already registered.
+func RegisterCustomCredential(typeName string, prototype interface{}, valid func(value interface{}) bool) {
+	typ := reflect.TypeOf(prototype)
+	if typ == nil || typ.Kind() != reflect.Struct {
+		panic("custom credential type '" + typeName + "' must be a struct")
+	}
+	if valid == nil {
+		panic("custom credential type '" + typeName + "' requires a valid function")
+	}
+	customCredentialTypesMutex.Lock()
+	defer customCredentialTypesMutex.Unlock()
+	if _, exists := customCredentialTypes[typeName]; exists {
+		panic("custom credential type '" + typeName + "' already registered")
+	}
+	customCredentialTypes[typeName] = customCredentialType{typ: typ, valid: valid}
+}
+
+func lookupCustomCredentialType(typeName string) (*customCredentialType, error) {
+	customCredentialTypesMutex.RLock()
+	defer customCredentialTypesMutex.RUnlock()
+	customType, ok := customCredentialTypes[typeName]
+	if !ok {
+		return nil, fmt.Errorf("custom credential type '%s' not registered", typeName)
+	}
+	return &customType, nil
+}
+
+func customKey(typeName, name string) string {
+	return typeName + "/" + name
+}
+
+func (c Config) customCredential(typeName, name string) (*yaml.Node, error) {
+	if node, ok := c.Custom[typeName][name]; ok {
+		return &node, nil
+	}
+	return nil, wrapErr(errNotFound, "custom "+typeName+" '"+name+"'")
+}
+
+// decode returns a pointer to a new value of the custom type decoded from the node.
+func (t customCredentialType) decode(node *yaml.Node) (reflect.Value, error) {
+	value := reflect.New(t.typ)
+	if err := node.Decode(value.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return value, nil
+}
+
+// fromEnv reads all fields of the custom type from the environment. Returns nil if no field is set or the
+// value is not valid.
+func (t customCredentialType) fromEnv(name string, envKey envKeyFunc) *reflect.Value {
+	node := yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range yamlFieldNames(t.typ) {
+		if value, ok := os.LookupEnv(envKey(name, field)); ok {
+			// untagged plain scalars, so the values are resolved to the field types while decoding
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: field},
+				&yaml.Node{Kind: yaml.ScalarNode, Value: value})
+		}
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+	value, err := t.decode(&node)
+	if err != nil || !t.valid(value.Elem().Interface()) {
+		return nil
+	}
+	return &value
+}
+
+// SetCustomCredentials stores the value of a registered custom credential type with the given name.
+func (c *ToolConfiguration) SetCustomCredentials(typeName, name string, value interface{}) error {
+	if name == "" {
+		return fmt.Errorf("custom credential name missing")
+	}
+	customType, err := lookupCustomCredentialType(typeName)
+	if err != nil {
+		return err
+	}
+	typed := reflect.ValueOf(value)
+	if typed.Kind() == reflect.Ptr && !typed.IsNil() {
+		typed = typed.Elem()
+	}
+	if !typed.IsValid() || typed.Type() != customType.typ {
+		return fmt.Errorf("value of type %T does not match custom credential type '%s'", value, typeName)
+	}
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+	if c.config.Custom == nil {
+		c.config.Custom = make(map[string]map[string]yaml.Node, 1)
+	}
+	if c.config.Custom[typeName] == nil {
+		c.config.Custom[typeName] = make(map[string]yaml.Node, 1)
+	}
+	c.config.Custom[typeName][name] = node
+	delete(c.customs, customKey(typeName, name))
+	return saveConfiguration(c.config)
+}
+
+// GetCustomCredentials find the custom credential of the registered type with the given name and stores it in the
+// value pointed to by out. Returns errNotFound if not found.
+func (c *ToolConfiguration) GetCustomCredentials(typeName, name string, out interface{}) error {
+	customType, err := lookupCustomCredentialType(typeName)
+	if err != nil {
+		return err
+	}
+	target := reflect.ValueOf(out)
+	if target.Kind() != reflect.Ptr || target.IsNil() || target.Elem().Type() != customType.typ {
+		return fmt.Errorf("out must be a non nil pointer to %s", customType.typ)
+	}
+	value, provenance, err := c.lookupCustom(customType, typeName, name)
+	if err != nil {
+		return err
+	}
+	resolved := reflect.New(customType.typ)
+	resolved.Elem().Set(value)
+	if err := c.resolveReferences(resolved.Interface(), provenance); err != nil {
+		return err
+	}
+	target.Elem().Set(resolved.Elem())
+	return nil
+}
+
+// lookupCustom returns the unresolved credentials from the environment, the cache or the config file and where the
+// values came from.
+func (c *ToolConfiguration) lookupCustom(customType *customCredentialType, typeName, name string) (reflect.Value, *Provenance, error) {
+	fields := yamlFieldNames(customType.typ)
+	if err := c.envCollision("custom."+typeName, name, fields); err != nil {
+		return reflect.Value{}, nil, err
+	}
+	if fromEnv := customType.fromEnv(name, c.envKey); fromEnv != nil {
+		return fromEnv.Elem(), c.newProvenance(SourceEnv, name, fields), nil
+	}
+	key := customKey(typeName, name)
+	source := SourceCache
+	customCred, ok := c.customs[key]
+	if !ok {
+		source = SourceFile
+		node, err := c.config.customCredential(typeName, name)
+		if err != nil {
+			return reflect.Value{}, nil, err
+		}
+		value, err := customType.decode(node)
+		if err != nil {
+			return reflect.Value{}, nil, wrapErr(fmt.Errorf("could not decode custom %s '%s': %w", typeName, name, err))
+		}
+		customCred = value.Elem().Interface()
+		c.customs[key] = customCred
+	}
+	merged := reflect.New(customType.typ)
+	merged.Elem().Set(reflect.ValueOf(customCred))
+	provenance, err := c.mergeEnvFields(merged.Interface(), name, fields, source)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	return merged.Elem(), provenance, nil
+}
+
+// GetAllCustomCredentials returns all custom credentials of the registered type by name.
+func (c *ToolConfiguration) GetAllCustomCredentials(typeName string) map[string]interface{} {
+	result := map[string]interface{}{}
+	customType, err := lookupCustomCredentialType(typeName)
+	if err != nil {
+		return result
+	}
+	for name, node := range c.config.Custom[typeName] {
+		node := node
+		value, err := customType.decode(&node)
+		if err != nil {
+			continue
+		}
+		result[name] = value.Elem().Interface()
+	}
+	return result
+}
+
+func (c *ToolConfiguration) customCredentialValid(typeName, name string) bool {
+	customType, err := lookupCustomCredentialType(typeName)
+	if err != nil {
+		return false
+	}
+	value := reflect.New(customType.typ)
+	if err := c.GetCustomCredentials(typeName, name, value.Interface()); err != nil {
+		return false
+	}
+	return customType.valid(value.Elem().Interface())
+}
@@ -0,0 +1,98 @@
+package toolsconfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type sftpCredential struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port,omitempty"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" secret:"true"`
+}
+
+const sftpType = "sftp"
+
+func init() {
+	RegisterCustomCredential(sftpType, sftpCredential{}, func(value interface{}) bool {
+		credential := value.(sftpCredential)
+		return credential.Host != "" && credential.Username != "" && credential.Password != ""
+	})
+}
+
+func TestRegisterCustomCredential(t *testing.T) {
+	require.Panics(t, func() { RegisterCustomCredential(sftpType, sftpCredential{}, func(interface{}) bool { return true }) })
+	require.Panics(t, func() { RegisterCustomCredential("string", "", func(interface{}) bool { return true }) })
+}
+
+func TestCustomCredentials(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, node.Encode(sftpCredential{Host: "sftp.example.com", Port: 2222, Username: "upload", Password: "secret"}))
+	var savedConfig = &Config{
+		Custom: map[string]map[string]yaml.Node{sftpType: {"upload": node}},
+	}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+
+	configuration, err := NewToolConfiguration(RequiredCustom(sftpType, "upload"))
+	require.NoError(t, err)
+
+	t.Run("Get", func(t *testing.T) {
+		var credential sftpCredential
+		require.NoError(t, configuration.GetCustomCredentials(sftpType, "upload", &credential))
+		require.Equal(t, sftpCredential{Host: "sftp.example.com", Port: 2222, Username: "upload", Password: "secret"}, credential)
+		var wrongType ServerCredential
+		require.Error(t, configuration.GetCustomCredentials(sftpType, "upload", &wrongType))
+		require.Error(t, configuration.GetCustomCredentials("unknown", "upload", &credential))
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		require.NoError(t, configuration.SetCustomCredentials(sftpType, "backup", sftpCredential{Host: "backup.example.com", Username: "backup", Password: "backup-secret"}))
+		require.Len(t, savedConfig.Custom[sftpType], 2)
+		var credential sftpCredential
+		require.NoError(t, configuration.GetCustomCredentials(sftpType, "backup", &credential))
+		require.Equal(t, "backup.example.com", credential.Host)
+
+		t.Setenv("TOOLSCONFIG_TEST_SFTP", "env-secret")
+		require.NoError(t, configuration.SetCustomCredentials(sftpType, "reference", sftpCredential{Host: "env:TOOLSCONFIG_TEST_SFTP", Username: "reference", Password: "env:TOOLSCONFIG_TEST_SFTP"}))
+		require.NoError(t, configuration.GetCustomCredentials(sftpType, "reference", &credential))
+		require.Equal(t, "env:TOOLSCONFIG_TEST_SFTP", credential.Host)
+		require.Equal(t, "env-secret", credential.Password)
+		delete(savedConfig.Custom[sftpType], "reference")
+		require.Len(t, configuration.GetAllCustomCredentials(sftpType), 2)
+		require.Error(t, configuration.SetCustomCredentials(sftpType, "backup", ServerCredential{}))
+		require.Error(t, configuration.SetCustomCredentials(sftpType, "backup", (*sftpCredential)(nil)))
+		require.Error(t, configuration.SetCustomCredentials(sftpType, "backup", nil))
+	})
+
+	t.Run("FromEnv", func(t *testing.T) {
+		for field, value := range map[string]string{"host": "env.example.com", "port": "22", "username": "envuser", "password": "envpassword"} {
+			require.NoError(t, os.Setenv(toEnvironmentKey("upload", field), value))
+			defer os.Unsetenv(toEnvironmentKey("upload", field))
+		}
+		var credential sftpCredential
+		require.NoError(t, configuration.GetCustomCredentials(sftpType, "upload", &credential))
+		require.Equal(t, sftpCredential{Host: "env.example.com", Port: 22, Username: "envuser", Password: "envpassword"}, credential)
+	})
+
+	t.Run("RequiredMissing", func(t *testing.T) {
+		_, err := NewToolConfiguration(RequiredCustom(sftpType, "missing"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "CustomCredential: sftp/missing")
+		missing, ok := savedConfig.Custom[sftpType]["missing"]
+		require.True(t, ok)
+		var credential sftpCredential
+		require.NoError(t, missing.Decode(&credential))
+		require.Equal(t, sftpCredential{}, credential)
+	})
+}
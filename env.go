@@ -0,0 +1,344 @@
+package toolsconfig
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvNamingStrategy returns the name of the environment variable of a credential field. The prefix is the value of
+// the EnvPrefix option, the key the identifier of the credential (url, name or key) and the fields the yaml name of
+// the field, e.g. 'username', or the name of a named field of a generic credential, e.g. 'token'. The names don't
+// contain the kind of the credential, see envCollision.
+type EnvNamingStrategy func(prefix, key string, fields ...string) string
+
+// envKeyFunc returns the name of the environment variable of the credential field with the naming and prefix of
+// the configuration.
+type envKeyFunc func(key string, fields ...string) string
+
+// DefaultEnvNaming joins the prefix, the key and the fields with '_', dots and dashes of the key are replaced by '_'.
+// e.g. 'testserver.io', 'username' => 'TESTSERVER_IO_USERNAME' or with prefix 'mytool' 'MYTOOL_TESTSERVER_IO_USERNAME'.
+func DefaultEnvNaming(prefix, key string, fields ...string) string {
+	name := toEnvironmentKey(key, fields...)
+	if prefix == "" {
+		return name
+	}
+	return toEnvironmentKey(prefix) + "_" + name
+}
+
+// DoubleUnderscoreEnvNaming joins the prefix, the key and the fields with '__', so the names of entries like
+// 'my-server' and 'my.server' can't collide with the fields.
+// e.g. 'testserver.io', 'username' => 'TESTSERVER_IO__USERNAME' or with prefix 'mytool' 'MYTOOL__TESTSERVER_IO__USERNAME'.
+func DoubleUnderscoreEnvNaming(prefix, key string, fields ...string) string {
+	elements := make([]string, 0, len(fields)+2)
+	if prefix != "" {
+		elements = append(elements, toEnvironmentKey(prefix))
+	}
+	elements = append(elements, toEnvironmentKey(key))
+	for _, field := range fields {
+		elements = append(elements, toEnvironmentKey(field))
+	}
+	return strings.Join(elements, "__")
+}
+
+// wellKnownAzureEnv are the environment variables of the azure SDKs and the azure cli, used as fallback if enabled
+// with the WellKnownEnv option.
+var wellKnownAzureEnv = map[string]string{
+	"subscriptionID": "AZURE_SUBSCRIPTION_ID",
+	"tenantID":       "AZURE_TENANT_ID",
+	"clientID":       "AZURE_CLIENT_ID",
+	"clientSecret":   "AZURE_CLIENT_SECRET",
+}
+
+// withWellKnownEnv returns a copy of the credential, empty fields are set from the well known azure environment
+// variables, and the fields set. Returns nil if the result is not valid.
+func (c AzureSubscriptionCredential) withWellKnownEnv() (*AzureSubscriptionCredential, []string) {
+	result := c
+	var fromEnv []string
+	for _, field := range azureSubscriptionEnvFields {
+		value, _ := structField(reflect.ValueOf(&result).Elem(), field)
+		if envValue, ok := os.LookupEnv(wellKnownAzureEnv[field]); ok && value.String() == "" {
+			value.SetString(envValue)
+			fromEnv = append(fromEnv, field)
+		}
+	}
+	if result.valid() {
+		return &result, fromEnv
+	}
+	return nil, nil
+}
+
+// Source is the origin of a credential or the value of a credential field.
+type Source string
+
+const (
+	// SourceFile the value is read from the config file.
+	SourceFile Source = "file"
+	// SourceCache the credential was read from the config file before and is taken from the cache.
+	SourceCache Source = "cache"
+	// SourceEnv the value is read from an environment variable.
+	SourceEnv Source = "env"
+)
+
+// Provenance tells where a credential and the values of its fields came from. The fields are the yaml names of the
+// fields (the names of the named fields of generic credentials).
+type Provenance struct {
+	// Source of the credential, 'env' if all fields are read from environment variables.
+	Source Source
+	// File is the config file, empty if the source is 'env'.
+	File string
+	// Fields are the sources of the fields.
+	Fields map[string]Source
+	// Variables are the names of the environment variables of the fields.
+	Variables map[string]string
+}
+
+func (c *ToolConfiguration) newProvenance(source Source, key string, fields []string) *Provenance {
+	provenance := &Provenance{
+		Source:    source,
+		Fields:    make(map[string]Source, len(fields)),
+		Variables: make(map[string]string, len(fields)),
+	}
+	fieldSource := source
+	if source != SourceEnv {
+		provenance.File = c.configFile
+		fieldSource = SourceFile
+	}
+	for _, field := range fields {
+		provenance.Fields[field] = fieldSource
+		provenance.Variables[field] = c.envKey(key, field)
+	}
+	return provenance
+}
+
+// mergeEnvFields overrides the fields of the struct the credential points to by the environment variables which are
+// set, if enabled by the MergeEnv option. The fields are the yaml names of the struct fields, the source is the source
+// of the credential ('file' or 'cache').
+func (c *ToolConfiguration) mergeEnvFields(credential interface{}, key string, fields []string, source Source) (*Provenance, error) {
+	provenance := c.newProvenance(source, key, fields)
+	if !c.mergeEnv {
+		return provenance, nil
+	}
+	if err := overrideEnvFields(credential, provenance, fields); err != nil {
+		return nil, err
+	}
+	return provenance, nil
+}
+
+// overrideEnvFields overrides the fields of the struct the credential points to by the variables of the provenance
+// which are set and marks them as read from the environment.
+func overrideEnvFields(credential interface{}, provenance *Provenance, fields []string) error {
+	value := reflect.ValueOf(credential).Elem()
+	for _, field := range fields {
+		variable := provenance.Variables[field]
+		envValue, ok := os.LookupEnv(variable)
+		if !ok {
+			continue
+		}
+		target, found := structField(value, field)
+		if !found {
+			continue
+		}
+		switch target.Kind() {
+		case reflect.String:
+			target.SetString(envValue)
+		case reflect.Int:
+			number, err := strconv.Atoi(envValue)
+			if err != nil {
+				return wrapErr(fmt.Errorf("invalid value of environment variable '%s': %w", variable, err))
+			}
+			target.SetInt(int64(number))
+		case reflect.Bool:
+			flag, err := strconv.ParseBool(envValue)
+			if err != nil {
+				return wrapErr(fmt.Errorf("invalid value of environment variable '%s': %w", variable, err))
+			}
+			target.SetBool(flag)
+		case reflect.Slice:
+			if target.Type().Elem().Kind() != reflect.String {
+				continue
+			}
+			target.Set(reflect.ValueOf(splitScopes(envValue)).Convert(target.Type()))
+		default:
+			continue
+		}
+		provenance.Fields[field] = SourceEnv
+	}
+	return nil
+}
+
+// envCollision returns an error if an environment variable of the fields of the credential is set and is also a
+// variable of an entry of another kind in the config file, e.g. 'PROD_USERNAME' of the database 'prod' and the
+// kubernetes cluster 'prod', so a variable is never used for the wrong credential.
+func (c *ToolConfiguration) envCollision(kind, key string, fields []string) error {
+	var bindings []EnvBinding
+	for _, field := range fields {
+		variable := c.envKey(key, field)
+		if _, ok := os.LookupEnv(variable); !ok {
+			continue
+		}
+		if bindings == nil {
+			bindings = c.EnvBindings()
+		}
+		for _, binding := range bindings {
+			if binding.Kind == kind {
+				continue
+			}
+			for _, other := range binding.Variables {
+				if other == variable {
+					return wrapErr(fmt.Errorf("environment variable '%s' of %s '%s' is also the variable of %s '%s'",
+						variable, kind, key, binding.Kind, binding.Name))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// structField returns the field of the struct with the yaml name, including the fields of inlined structs.
+func structField(value reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if field.Type.Kind() == reflect.Struct && (field.Anonymous || (len(tag) > 1 && tag[1] == "inline")) {
+			if inlined, found := structField(value.Field(i), name); found {
+				return inlined, true
+			}
+			continue
+		}
+		if yamlFieldName(field) == name {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Provenance returns where the credential of the kind (the yaml name of the section of the config file,
+// 'custom.<type>' for custom credentials) and identifier came from.
+func (c *ToolConfiguration) Provenance(kind, id string) (*Provenance, error) {
+	_, provenance, err := c.lookupKind(kind, id)
+	if err != nil {
+		return nil, err
+	}
+	return provenance, nil
+}
+
+// lookupKind returns the unresolved credential of the kind and where it came from.
+func (c *ToolConfiguration) lookupKind(kind, id string) (interface{}, *Provenance, error) {
+	switch kind {
+	case "servers":
+		return c.lookupServer(id)
+	case "azureSubscriptions":
+		return c.lookupAzureSubscription(id)
+	case "generics":
+		return c.lookupGeneric(id)
+	case "databases":
+		return c.lookupDatabase(id)
+	case "kubernetes":
+		return c.lookupKubernetes(id)
+	case "oauth2":
+		return c.lookupOAuth2(id)
+	}
+	typeName := strings.TrimPrefix(kind, "custom.")
+	customType, err := lookupCustomCredentialType(typeName)
+	if !strings.HasPrefix(kind, "custom.") || err != nil {
+		return nil, nil, wrapErr(fmt.Errorf("unknown credential kind '%s'", kind))
+	}
+	value, provenance, err := c.lookupCustom(customType, typeName, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value.Interface(), provenance, nil
+}
+
+// EnvBinding lists the environment variables of a credential field.
+type EnvBinding struct {
+	// Kind is the section of the config file, e.g. 'servers'.
+	Kind string
+	// Name is the identifier of the entry (url, name or key).
+	Name  string
+	Field string
+	// Variables are the names of the environment variables, in the order they are used.
+	Variables []string
+}
+
+// IsSet returns true if one of the environment variables is set.
+func (b EnvBinding) IsSet() bool {
+	for _, variable := range b.Variables {
+		if _, ok := os.LookupEnv(variable); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	serverEnvFields            = []string{"username", "password"}
+	azureSubscriptionEnvFields = []string{"subscriptionID", "tenantID", "clientID", "clientSecret"}
+	databaseEnvFields          = []string{"driver", "host", "port", "database", "username", "password", "tlsMode"}
+	kubernetesEnvFields        = []string{"server", "certificateAuthorityData", "token", "clientCertificateData", "clientKeyData", "namespace"}
+	oauth2EnvFields            = []string{"tokenURL", "clientID", "clientSecret", "scopes", "refreshToken"}
+)
+
+// EnvBindings returns the environment variables honored for the entries of the config file.
+func (c *ToolConfiguration) EnvBindings() []EnvBinding {
+	var bindings []EnvBinding
+	add := func(kind, name string, fields []string) {
+		for _, field := range fields {
+			binding := EnvBinding{Kind: kind, Name: name, Field: field, Variables: []string{c.envKey(name, field)}}
+			if variable, ok := wellKnownAzureEnv[field]; ok && kind == "azureSubscriptions" && c.wellKnownEnv {
+				binding.Variables = append(binding.Variables, variable)
+			}
+			bindings = append(bindings, binding)
+		}
+	}
+	for _, server := range c.config.Servers {
+		add("servers", server.URL, serverEnvFields)
+	}
+	for _, subscription := range c.config.AzureSubscriptions {
+		add("azureSubscriptions", subscription.Name, azureSubscriptionEnvFields)
+	}
+	for _, generic := range c.config.Generic {
+		fields := []string{"value"}
+		for field := range generic.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields[1:])
+		add("generics", generic.Key, fields)
+	}
+	for _, database := range c.config.Databases {
+		add("databases", database.Name, databaseEnvFields)
+	}
+	for _, cluster := range c.config.Kubernetes {
+		add("kubernetes", cluster.Name, kubernetesEnvFields)
+	}
+	for _, client := range c.config.OAuth2 {
+		add("oauth2", client.Name, oauth2EnvFields)
+	}
+	typeNames := make([]string, 0, len(c.config.Custom))
+	for typeName := range c.config.Custom {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+	for _, typeName := range typeNames {
+		customType, err := lookupCustomCredentialType(typeName)
+		if err != nil {
+			continue
+		}
+		names := make([]string, 0, len(c.config.Custom[typeName]))
+		for name := range c.config.Custom[typeName] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add("custom."+typeName, name, yamlFieldNames(customType.typ))
+		}
+	}
+	return bindings
+}
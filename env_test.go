@@ -0,0 +1,197 @@
+package toolsconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEnvNaming(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy EnvNamingStrategy
+		prefix   string
+		key      string
+		fields   []string
+		want     string
+	}{
+		{name: "default", strategy: DefaultEnvNaming, key: "testserver.io", fields: []string{"username"}, want: "TESTSERVER_IO_USERNAME"},
+		{name: "default with prefix", strategy: DefaultEnvNaming, prefix: "my-tool", key: "testserver.io", fields: []string{"username"}, want: "MY_TOOL_TESTSERVER_IO_USERNAME"},
+		{name: "double underscore", strategy: DoubleUnderscoreEnvNaming, key: "my-server", fields: []string{"clientSecret"}, want: "MY_SERVER__CLIENTSECRET"},
+		{name: "double underscore with prefix", strategy: DoubleUnderscoreEnvNaming, prefix: "mytool", key: "testserver.io", fields: []string{"username"}, want: "MYTOOL__TESTSERVER_IO__USERNAME"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.strategy(tt.prefix, tt.key, tt.fields...))
+		})
+	}
+}
+
+func TestOverrideEnvFields(t *testing.T) {
+	type credential struct {
+		Endpoint string `yaml:"endpoint"`
+		Insecure bool   `yaml:"insecure,omitempty"`
+		Labels   `yaml:",inline"`
+	}
+	fields := []string{"endpoint", "insecure", "tags"}
+	provenance := &Provenance{Fields: map[string]Source{}, Variables: map[string]string{}}
+	for _, field := range fields {
+		provenance.Fields[field] = SourceFile
+		provenance.Variables[field] = toEnvironmentKey("override", field)
+	}
+	t.Setenv("OVERRIDE_INSECURE", "true")
+	t.Setenv("OVERRIDE_TAGS", "team,prod")
+
+	value := credential{Endpoint: "https://example.com"}
+	require.NoError(t, overrideEnvFields(&value, provenance, fields))
+	require.Equal(t, credential{Endpoint: "https://example.com", Insecure: true, Labels: Labels{Tags: []string{"team", "prod"}}}, value)
+	require.Equal(t, map[string]Source{"endpoint": SourceFile, "insecure": SourceEnv, "tags": SourceEnv}, provenance.Fields)
+
+	t.Setenv("OVERRIDE_INSECURE", "maybe")
+	err := overrideEnvFields(&value, provenance, fields)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "OVERRIDE_INSECURE")
+}
+
+func TestEnvConfiguration(t *testing.T) {
+	var savedConfig = &Config{
+		Servers: []ServerCredential{
+			{URL: "envserver.io", Username: "user", Password: "password"},
+		},
+		AzureSubscriptions: []AzureSubscriptionCredential{
+			{Name: "env-subscription", SubscriptionID: "subscription-id", TenantID: "tenant-id"},
+		},
+	}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+
+	t.Run("prefix", func(t *testing.T) {
+		t.Setenv("ENVSERVER_IO_USERNAME", "unprefixed")
+		t.Setenv("ENVSERVER_IO_PASSWORD", "unprefixed")
+		t.Setenv("MYTOOL__ENVSERVER_IO__USERNAME", "env-user")
+		t.Setenv("MYTOOL__ENVSERVER_IO__PASSWORD", "env-password")
+		configuration, err := NewToolConfiguration(EnvPrefix("mytool"), EnvNaming(DoubleUnderscoreEnvNaming))
+		require.NoError(t, err)
+		server, err := configuration.GetServerCredentials("envserver.io")
+		require.NoError(t, err)
+		require.Equal(t, "env-user", server.Username)
+		require.Equal(t, "env-password", server.Password)
+	})
+
+	t.Run("well known azure env", func(t *testing.T) {
+		t.Setenv("AZURE_CLIENT_ID", "client-id")
+		t.Setenv("AZURE_CLIENT_SECRET", "client-secret")
+		configuration, err := NewToolConfiguration()
+		require.NoError(t, err)
+		subscription, err := configuration.GetAzureSubscriptionCredentials("env-subscription")
+		require.NoError(t, err)
+		require.Empty(t, subscription.ClientID)
+
+		configuration, err = NewToolConfiguration(WellKnownEnv(true), RequiredSubscription("env-subscription"))
+		require.NoError(t, err)
+		subscription, err = configuration.GetAzureSubscriptionCredentials("env-subscription")
+		require.NoError(t, err)
+		require.Equal(t, "subscription-id", subscription.SubscriptionID)
+		require.Equal(t, "tenant-id", subscription.TenantID)
+		require.Equal(t, "client-id", subscription.ClientID)
+		require.Equal(t, "client-secret", subscription.ClientSecret)
+
+		t.Setenv("AZURE_SUBSCRIPTION_ID", "env-subscription-id")
+		t.Setenv("AZURE_TENANT_ID", "env-tenant-id")
+		_, err = configuration.GetAzureSubscriptionCredentials("typo-subscription")
+		require.Error(t, err)
+		subscription, err = configuration.GetAzureSubscriptionCredentials("env-subscription-id")
+		require.NoError(t, err)
+		require.Equal(t, "env-tenant-id", subscription.TenantID)
+		subscription, err = configuration.GetAzureSubscriptionCredentials("")
+		require.NoError(t, err)
+		require.Equal(t, "env-subscription-id", subscription.SubscriptionID)
+	})
+
+	t.Run("merge env", func(t *testing.T) {
+		t.Setenv("ENVSERVER_IO_PASSWORD", "env-password")
+		t.Setenv("ENV_SUBSCRIPTION_CLIENTSECRET", "env-secret")
+		configuration, err := NewToolConfiguration()
+		require.NoError(t, err)
+		server, err := configuration.GetServerCredentials("envserver.io")
+		require.NoError(t, err)
+		require.Equal(t, "password", server.Password)
+
+		configuration, err = NewToolConfiguration(MergeEnv(true))
+		require.NoError(t, err)
+		server, err = configuration.GetServerCredentials("envserver.io")
+		require.NoError(t, err)
+		require.Equal(t, "user", server.Username)
+		require.Equal(t, "env-password", server.Password)
+		require.Equal(t, "password", savedConfig.Servers[0].Password)
+		provenance, err := configuration.Provenance("servers", "envserver.io")
+		require.NoError(t, err)
+		require.Equal(t, map[string]Source{"username": SourceFile, "password": SourceEnv}, provenance.Fields)
+
+		subscription, err := configuration.GetAzureSubscriptionCredentials("env-subscription")
+		require.NoError(t, err)
+		require.Equal(t, "tenant-id", subscription.TenantID)
+		require.Equal(t, "env-secret", subscription.ClientSecret)
+		provenance, err = configuration.Provenance("azureSubscriptions", "env-subscription")
+		require.NoError(t, err)
+		require.Equal(t, SourceEnv, provenance.Fields["clientSecret"])
+		require.Equal(t, SourceFile, provenance.Fields["clientID"])
+
+		_, err = configuration.Provenance("unknown", "envserver.io")
+		require.Error(t, err)
+	})
+
+	t.Run("merge env invalid value", func(t *testing.T) {
+		t.Setenv("ENV_DB_PORT", "not-a-number")
+		savedConfig.Databases = []DatabaseCredential{{Name: "env-db", Driver: "postgres", Host: "localhost", Port: 5432}}
+		defer func() { savedConfig.Databases = nil }()
+		configuration, err := NewToolConfiguration(MergeEnv(true))
+		require.NoError(t, err)
+		_, err = configuration.GetDatabaseCredentials("env-db")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "ENV_DB_PORT")
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		var node yaml.Node
+		require.NoError(t, node.Encode(sftpCredential{Host: "sftp.example.com", Username: "upload", Password: "secret"}))
+		savedConfig.Databases = []DatabaseCredential{{Name: "shared", Driver: "postgres", Host: "localhost", Username: "user", Password: "password"}}
+		savedConfig.Custom = map[string]map[string]yaml.Node{sftpType: {"shared": node}}
+		defer func() { savedConfig.Databases, savedConfig.Custom = nil, nil }()
+		configuration, err := NewToolConfiguration(MergeEnv(true))
+		require.NoError(t, err)
+		_, err = configuration.GetDatabaseCredentials("shared")
+		require.NoError(t, err)
+
+		t.Setenv("SHARED_PASSWORD", "env-password")
+		_, err = configuration.GetDatabaseCredentials("shared")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "environment variable 'SHARED_PASSWORD' of databases 'shared' is also the variable of custom.sftp 'shared'")
+		var credential sftpCredential
+		require.Error(t, configuration.GetCustomCredentials(sftpType, "shared", &credential))
+		configuration, err = NewToolConfiguration(MergeEnv(true), EnvPrefix("mytool"))
+		require.NoError(t, err)
+		database, err := configuration.GetDatabaseCredentials("shared")
+		require.NoError(t, err)
+		require.Equal(t, "password", database.Password, "the variables with prefix are not set")
+	})
+
+	t.Run("bindings", func(t *testing.T) {
+		t.Setenv("MYTOOL_ENVSERVER_IO_USERNAME", "env-user")
+		configuration, err := NewToolConfiguration(EnvPrefix("mytool"), WellKnownEnv(true))
+		require.NoError(t, err)
+		bindings := configuration.EnvBindings()
+		require.Len(t, bindings, 6)
+		require.Equal(t, EnvBinding{Kind: "servers", Name: "envserver.io", Field: "username", Variables: []string{"MYTOOL_ENVSERVER_IO_USERNAME"}}, bindings[0])
+		require.True(t, bindings[0].IsSet())
+		require.False(t, bindings[1].IsSet())
+		require.Equal(t, []string{"MYTOOL_ENV_SUBSCRIPTION_CLIENTID", "AZURE_CLIENT_ID"}, bindings[4].Variables)
+	})
+}
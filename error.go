@@ -35,3 +35,5 @@ func wrapErr(err error, missing ...string) *ConfigError {
 }
 
 var errNotFound = errors.New("not found")
+
+var errReservedGenericField = errors.New("the field name 'value' of generic credentials is reserved for the value")
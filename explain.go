@@ -0,0 +1,154 @@
+package toolsconfig
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maskedValue replaces secret values in explanations.
+const maskedValue = "****"
+
+// publicFields are the fields shown unmasked in explanations. All other fields, the named fields of generic
+// credentials and all fields of custom credentials are masked.
+var publicFields = map[string]bool{
+	"url": true, "name": true, "username": true, "subscriptionID": true, "tenantID": true, "clientID": true,
+	"driver": true, "host": true, "port": true, "database": true, "tlsMode": true, "server": true,
+	"namespace": true, "certificateAuthorityData": true, "clientCertificateData": true, "tokenURL": true,
+	"scopes": true,
+}
+
+// ExplainOption is an option of Explain.
+type ExplainOption func(o *explainOptions)
+
+type explainOptions struct {
+	resolve bool
+}
+
+// ExplainResolve resolves the secret references and variables of the values, e.g. to check that they can be resolved.
+// This runs the commands of 'cmd:' references and reads Vault and Key Vault secrets. Default is 'false', only the
+// references are shown.
+func ExplainResolve(value bool) ExplainOption {
+	return func(o *explainOptions) {
+		o.resolve = value
+	}
+}
+
+// Explanation describes how a credential was resolved, secret values are masked.
+type Explanation struct {
+	Kind string
+	ID   string
+	// Provenance tells where the credential and its fields came from.
+	Provenance Provenance
+	// Match is the matching server entry, only set for servers read from the config file.
+	Match  *ServerMatch
+	Fields []FieldExplanation
+}
+
+// FieldExplanation describes how the value of a field was resolved.
+type FieldExplanation struct {
+	Field  string
+	Source Source
+	// Variable is the name of the environment variable of the field.
+	Variable string
+	// Reference is the unresolved value, if it contains a secret reference or variables.
+	Reference string
+	// Value is the value, masked unless the field is not a secret. The value of a reference is only set if resolved,
+	// see ExplainResolve.
+	Value string
+	// Error is set if the value could not be resolved.
+	Error string
+}
+
+// Explain returns how the credential of the kind (the yaml name of the section of the config file, 'custom.<type>'
+// for custom credentials) and identifier is resolved: the source of the credential and of every field, the environment
+// variables and the secret references. Secret values are masked, references are not resolved unless ExplainResolve
+// is given.
+func (c *ToolConfiguration) Explain(kind, id string, options ...ExplainOption) (*Explanation, error) {
+	var opts explainOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	credential, provenance, err := c.lookupKind(kind, id)
+	if err != nil {
+		return nil, err
+	}
+	explanation := &Explanation{Kind: kind, ID: id, Provenance: *provenance}
+	if kind == "servers" && provenance.Source != SourceEnv {
+		explanation.Match, _ = c.ExplainServerMatch(id)
+	}
+	value := reflect.Indirect(reflect.ValueOf(credential))
+	maskAll := value.Type() == reflect.TypeOf(GenericCredential{}) || strings.HasPrefix(kind, "custom.")
+	for _, field := range explainFieldOrder(value, provenance) {
+		raw, _ := entryField(credential, field)
+		fieldExplanation := FieldExplanation{
+			Field:    field,
+			Source:   provenance.Fields[field],
+			Variable: provenance.Variables[field],
+		}
+		resolved := raw
+		// like resolveReferences: values of environment variables are kept, only secret fields have references
+		secret := isSecretEntryField(credential, field)
+		_, _, isReference := c.secretReference(raw)
+		if fieldExplanation.Source != SourceEnv && ((secret && isReference) || strings.Contains(raw, "${")) {
+			fieldExplanation.Reference = maskReference(raw)
+			resolved = ""
+			if opts.resolve {
+				resolved, err = c.resolveString(raw, secret)
+				if err != nil {
+					fieldExplanation.Error = err.Error()
+				}
+			}
+		}
+		fieldExplanation.Value = maskValue(field, resolved, maskAll)
+		explanation.Fields = append(explanation.Fields, fieldExplanation)
+	}
+	return explanation, nil
+}
+
+// maskReference masks the literal value of a 'raw:' reference.
+func maskReference(reference string) string {
+	if strings.HasPrefix(reference, "raw:") {
+		return "raw:" + maskValue("", reference[len("raw:"):], true)
+	}
+	return reference
+}
+
+// explainFieldOrder returns the fields of the provenance in the order of the struct fields, the named fields of
+// generic credentials sorted by name.
+func explainFieldOrder(value reflect.Value, provenance *Provenance) []string {
+	var fields, named []string
+	for _, field := range yamlFieldNames(value.Type()) {
+		if _, ok := provenance.Fields[field]; ok {
+			fields = append(fields, field)
+		}
+	}
+	for field := range provenance.Fields {
+		if _, found := structField(value, field); !found {
+			named = append(named, field)
+		}
+	}
+	sort.Strings(named)
+	return append(fields, named...)
+}
+
+// maskValue masks the value unless the field is public, all values are masked with maskAll.
+func maskValue(field, value string, maskAll bool) string {
+	if value == "" || (publicFields[field] && !maskAll) {
+		return value
+	}
+	return maskedValue
+}
+
+func (e Explanation) String() string {
+	var builder strings.Builder
+	_, _ = fmt.Fprintf(&builder, "%s '%s' from %s", e.Kind, e.ID, e.Provenance.Source)
+	if e.Provenance.File != "" {
+		_, _ = fmt.Fprintf(&builder, " (%s)", e.Provenance.File)
+	}
+	if e.Match != nil {
+		_, _ = fmt.Fprintf(&builder, ", %s", e.Match)
+	}
+	return builder.String()
+}
@@ -0,0 +1,139 @@
+package toolsconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExplain(t *testing.T) {
+	t.Setenv("EXPLAIN_SECRET", "secret-password")
+	t.Setenv("EXPLAIN_IO_USERNAME", "cmd:env-user")
+	var savedConfig = &Config{
+		Variables: map[string]string{"user": "file-user"},
+		Servers: []ServerCredential{
+			{URL: "https://explain.io", Username: "${user}", Password: "env:EXPLAIN_SECRET"},
+		},
+		Generic: []GenericCredential{
+			{Key: "explain-generic", Value: "plain", Fields: map[string]string{"token": "env:EXPLAIN_MISSING", "literal": "raw:env:literal"}},
+		},
+		Databases: []DatabaseCredential{
+			{Name: "explain-db", Driver: "postgres", Host: "env:EXPLAIN_HOST", Username: "db-user", Password: "env:EXPLAIN_SECRET"},
+		},
+	}
+	var node yaml.Node
+	require.NoError(t, node.Encode(sftpCredential{Host: "sftp.example.com", Username: "upload", Password: "secret"}))
+	savedConfig.Custom = map[string]map[string]yaml.Node{sftpType: {"upload": node}}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+	configuration, err := NewToolConfiguration(MergeEnv(true))
+	require.NoError(t, err)
+	configFile := "unittestconfig.yaml"
+
+	tests := []struct {
+		name    string
+		kind    string
+		id      string
+		options []ExplainOption
+		source  Source
+		fields  []FieldExplanation
+	}{
+		{
+			name: "server from file", kind: "servers", id: "explain.io/path", source: SourceFile,
+			fields: []FieldExplanation{
+				{Field: "username", Source: SourceFile, Variable: "EXPLAIN_IO/PATH_USERNAME", Reference: "${user}"},
+				{Field: "password", Source: SourceFile, Variable: "EXPLAIN_IO/PATH_PASSWORD", Reference: "env:EXPLAIN_SECRET"},
+			},
+		},
+		{
+			name: "server resolved", kind: "servers", id: "explain.io/path", options: []ExplainOption{ExplainResolve(true)}, source: SourceCache,
+			fields: []FieldExplanation{
+				{Field: "username", Source: SourceFile, Variable: "EXPLAIN_IO/PATH_USERNAME", Value: "file-user", Reference: "${user}"},
+				{Field: "password", Source: SourceFile, Variable: "EXPLAIN_IO/PATH_PASSWORD", Value: maskedValue, Reference: "env:EXPLAIN_SECRET"},
+			},
+		},
+		{
+			name: "server from env", kind: "servers", id: "explain.io", options: []ExplainOption{ExplainResolve(true)}, source: SourceFile,
+			fields: []FieldExplanation{
+				{Field: "username", Source: SourceEnv, Variable: "EXPLAIN_IO_USERNAME", Value: "cmd:env-user"},
+				{Field: "password", Source: SourceFile, Variable: "EXPLAIN_IO_PASSWORD", Value: maskedValue, Reference: "env:EXPLAIN_SECRET"},
+			},
+		},
+		{
+			name: "generic", kind: "generics", id: "explain-generic", source: SourceFile,
+			fields: []FieldExplanation{
+				{Field: "value", Source: SourceFile, Variable: "EXPLAIN_GENERIC_VALUE", Value: maskedValue},
+				{Field: "literal", Source: SourceFile, Variable: "EXPLAIN_GENERIC_LITERAL", Reference: "raw:" + maskedValue},
+				{Field: "token", Source: SourceFile, Variable: "EXPLAIN_GENERIC_TOKEN", Reference: "env:EXPLAIN_MISSING"},
+			},
+		},
+		{
+			name: "generic resolved", kind: "generics", id: "explain-generic", options: []ExplainOption{ExplainResolve(true)}, source: SourceCache,
+			fields: []FieldExplanation{
+				{Field: "value", Source: SourceFile, Variable: "EXPLAIN_GENERIC_VALUE", Value: maskedValue},
+				{Field: "literal", Source: SourceFile, Variable: "EXPLAIN_GENERIC_LITERAL", Reference: "raw:" + maskedValue, Value: maskedValue},
+				{Field: "token", Source: SourceFile, Variable: "EXPLAIN_GENERIC_TOKEN", Reference: "env:EXPLAIN_MISSING",
+					Error: "could not resolve secret reference 'env:EXPLAIN_MISSING': environment variable 'EXPLAIN_MISSING' not set"},
+			},
+		},
+		{
+			name: "database", kind: "databases", id: "explain-db", options: []ExplainOption{ExplainResolve(true)}, source: SourceFile,
+			fields: []FieldExplanation{
+				{Field: "driver", Source: SourceFile, Variable: "EXPLAIN_DB_DRIVER", Value: "postgres"},
+				{Field: "host", Source: SourceFile, Variable: "EXPLAIN_DB_HOST", Value: "env:EXPLAIN_HOST"},
+				{Field: "port", Source: SourceFile, Variable: "EXPLAIN_DB_PORT", Value: "0"},
+				{Field: "database", Source: SourceFile, Variable: "EXPLAIN_DB_DATABASE"},
+				{Field: "username", Source: SourceFile, Variable: "EXPLAIN_DB_USERNAME", Value: "db-user"},
+				{Field: "password", Source: SourceFile, Variable: "EXPLAIN_DB_PASSWORD", Value: maskedValue, Reference: "env:EXPLAIN_SECRET"},
+				{Field: "tlsMode", Source: SourceFile, Variable: "EXPLAIN_DB_TLSMODE"},
+			},
+		},
+		{
+			name: "custom", kind: "custom." + sftpType, id: "upload", source: SourceFile,
+			fields: []FieldExplanation{
+				{Field: "host", Source: SourceFile, Variable: "UPLOAD_HOST", Value: maskedValue},
+				{Field: "port", Source: SourceFile, Variable: "UPLOAD_PORT", Value: maskedValue},
+				{Field: "username", Source: SourceFile, Variable: "UPLOAD_USERNAME", Value: maskedValue},
+				{Field: "password", Source: SourceFile, Variable: "UPLOAD_PASSWORD", Value: maskedValue},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation, err := configuration.Explain(tt.kind, tt.id, tt.options...)
+			require.NoError(t, err)
+			require.Equal(t, tt.source, explanation.Provenance.Source)
+			require.Equal(t, configFile, explanation.Provenance.File)
+			for i, field := range explanation.Fields {
+				if tt.fields[i].Error != "" {
+					require.Contains(t, field.Error, tt.fields[i].Error)
+					field.Error = tt.fields[i].Error
+				}
+				require.Equal(t, tt.fields[i], field)
+			}
+			require.Len(t, explanation.Fields, len(tt.fields))
+		})
+	}
+
+	t.Run("cache and match", func(t *testing.T) {
+		explanation, err := configuration.Explain("servers", "explain.io/path")
+		require.NoError(t, err)
+		require.Equal(t, SourceCache, explanation.Provenance.Source)
+		require.Equal(t, MatchHost, explanation.Match.Rule)
+		require.Equal(t, "servers 'explain.io/path' from cache ("+configFile+"), server 'explain.io/path' matched entry 'https://explain.io' (host)", explanation.String())
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := configuration.Explain("servers", "unknown.io")
+		require.Error(t, err)
+		_, err = configuration.Explain("favourites", "explain.io")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,252 @@
+package toolsconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FavouriteOrder is the sort order of favourites.
+type FavouriteOrder string
+
+const (
+	// FavouriteOrderName sorts by name.
+	FavouriteOrderName FavouriteOrder = "name"
+	// FavouriteOrderRecent sorts by the last run, most recent first. Favourites never run are sorted by creation time.
+	FavouriteOrderRecent FavouriteOrder = "recent"
+	// FavouriteOrderMostUsed sorts by the run count, highest first.
+	FavouriteOrderMostUsed FavouriteOrder = "most-used"
+)
+
+// SortFavourites sorts the favourites in the order, favourites with equal values are sorted by name.
+func SortFavourites(favourites []Favourite, order FavouriteOrder) {
+	sort.SliceStable(favourites, func(i, j int) bool {
+		a, b := favourites[i], favourites[j]
+		switch order {
+		case FavouriteOrderRecent:
+			if !a.lastUsed().Equal(b.lastUsed()) {
+				return a.lastUsed().After(b.lastUsed())
+			}
+		case FavouriteOrderMostUsed:
+			if a.RunCount != b.RunCount {
+				return a.RunCount > b.RunCount
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// ParseFavouriteOrder returns the order with the given name.
+func ParseFavouriteOrder(value string) (FavouriteOrder, error) {
+	switch order := FavouriteOrder(value); order {
+	case FavouriteOrderName, FavouriteOrderRecent, FavouriteOrderMostUsed:
+		return order, nil
+	}
+	return "", fmt.Errorf("unknown favourite order '%s' (valid: %s, %s, %s)", value, FavouriteOrderName, FavouriteOrderRecent, FavouriteOrderMostUsed)
+}
+
+func (s Favourite) lastUsed() time.Time {
+	if s.LastRun.IsZero() {
+		return s.Created
+	}
+	return s.LastRun
+}
+
+// IsChain returns true if the favourite is a chain of steps.
+func (s Favourite) IsChain() bool {
+	return len(s.Steps) > 0
+}
+
+// CommandLine returns the command line of the favourite run by the tool, or the steps of a chain joined by ' -> '.
+func (s Favourite) CommandLine(tool string) string {
+	if !s.IsChain() {
+		return fmt.Sprintf("'%s %s'", tool, strings.Join(s.Args, " "))
+	}
+	steps := make([]string, len(s.Steps))
+	for idx, step := range s.Steps {
+		steps[idx] = step.String()
+	}
+	return strings.Join(steps, " -> ")
+}
+
+func (s FavouriteStep) String() string {
+	if s.Favourite != "" {
+		return s.Favourite
+	}
+	return "'" + strings.Join(s.Args, " ") + "'"
+}
+
+// FavouriteChain returns the favourites run by the favourite in their order: the favourite itself if it is no chain,
+// otherwise a favourite for each step. Steps referencing chains are replaced by their steps. Inline steps are named
+// '<chain>[<step>]'. The defaults of the chain override the defaults of the steps. Returns an error if a referenced
+// favourite does not exist or a chain references itself.
+func (c *ToolConfiguration) FavouriteChain(tool, name string) ([]Favourite, error) {
+	return c.favouriteChain(tool, name, nil)
+}
+
+func (c *ToolConfiguration) favouriteChain(tool, name string, stack []string) ([]Favourite, error) {
+	for _, element := range stack {
+		if element == name {
+			return nil, wrapErr(fmt.Errorf("cyclic favourite chain %s -> %s", strings.Join(stack, " -> "), name))
+		}
+	}
+	stack = append(stack, name)
+	favourite, err := c.GetFavourite(tool, name)
+	if err != nil {
+		return nil, wrapErr(errNotFound, "favourite '"+name+"'")
+	}
+	if !favourite.IsChain() {
+		return []Favourite{*favourite}, nil
+	}
+	var favourites []Favourite
+	for idx, step := range favourite.Steps {
+		var stepFavourites []Favourite
+		if step.Favourite != "" {
+			stepFavourites, err = c.favouriteChain(tool, step.Favourite, stack)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			stepFavourites = []Favourite{{Name: fmt.Sprintf("%s[%d]", name, idx+1), Args: step.Args, Positional: favourite.Positional}}
+		}
+		for _, stepFavourite := range stepFavourites {
+			defaults := make(map[string]string, len(stepFavourite.Defaults)+len(favourite.Defaults))
+			for key, value := range stepFavourite.Defaults {
+				defaults[key] = value
+			}
+			for key, value := range favourite.Defaults {
+				defaults[key] = value
+			}
+			stepFavourite.Defaults = defaults
+			favourites = append(favourites, stepFavourite)
+		}
+	}
+	return favourites, nil
+}
+
+// DefaultConfirmPatterns match command lines targeting production, e.g. '--env prod' or '--environment=production'.
+var DefaultConfirmPatterns = []string{`(^|\s)--?(env|environment|stage)[=\s](prod|production)(\s|$)`}
+
+// RequiresConfirmation returns the reason if the expanded args of the favourite must be confirmed before running:
+// the confirm attribute of the favourite or a confirm pattern matching the args joined by spaces. The patterns are
+// set with the option ConfirmPatterns and extended by the confirmPatterns of the config file. Returns an empty
+// reason if no confirmation is required.
+func (c *ToolConfiguration) RequiresConfirmation(favourite Favourite, args []string) (string, error) {
+	if favourite.Confirm {
+		return fmt.Sprintf("favourite '%s' requires confirmation", favourite.Name), nil
+	}
+	commandLine := strings.Join(args, " ")
+	for _, pattern := range append(append([]string{}, c.confirmPatterns...), c.config.ConfirmPatterns...) {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", wrapErr(fmt.Errorf("invalid confirm pattern '%s': %w", pattern, err))
+		}
+		if match := expression.FindString(commandLine); match != "" {
+			return fmt.Sprintf("'%s' matches confirm pattern '%s'", strings.TrimSpace(match), pattern), nil
+		}
+	}
+	return "", nil
+}
+
+// placeholderPattern matches the named placeholders '{{name}}', the positional placeholders '$1' and the escaped '$$'.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][\w.-]*)\s*\}\}|\$([1-9][0-9]*)|\$\$`)
+
+// namedPlaceholderPattern matches the named placeholders '{{name}}' of favourites without positional placeholders.
+var namedPlaceholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][\w.-]*)\s*\}\}()`)
+
+// placeholders returns the pattern of the placeholders of the favourite. '$1' and '$$' are placeholders only if the
+// favourite has positional placeholders, so the args of favourites saved before keep a literal '$'.
+func (s Favourite) placeholders() *regexp.Regexp {
+	if s.Positional {
+		return placeholderPattern
+	}
+	return namedPlaceholderPattern
+}
+
+// PlaceholderPrompt asks for the value of a placeholder without value and default.
+type PlaceholderPrompt func(name string) (string, error)
+
+// Placeholders returns the names of the placeholders of the favourite in the order of their first appearance.
+// Positional placeholders are named by their number, e.g. '1' for '$1'.
+func (s Favourite) Placeholders() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, arg := range s.Args {
+		for _, match := range s.placeholders().FindAllStringSubmatch(arg, -1) {
+			name := match[1] + match[2]
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Expand replaces the placeholders of the arguments. A value is taken from the values, the defaults of the favourite
+// or the prompt, in this order. Use '$$' for a literal '$' in favourites with positional placeholders. Returns an
+// error with all missing placeholders if there is no value and no prompt (the prompt may be nil).
+func (s Favourite) Expand(values map[string]string, prompt PlaceholderPrompt) ([]string, error) {
+	resolved := make(map[string]string, len(values))
+	var missing []string
+	for _, name := range s.Placeholders() {
+		value, ok := values[name]
+		if !ok {
+			value, ok = s.Defaults[name]
+		}
+		if !ok && prompt != nil {
+			var err error
+			value, err = prompt(name)
+			if err != nil {
+				return nil, err
+			}
+			ok = true
+		}
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		resolved[name] = value
+	}
+	if len(missing) > 0 {
+		return nil, wrapErr(fmt.Errorf("missing values for placeholders of favourite '%s'", s.Name), missing...)
+	}
+	pattern := s.placeholders()
+	args := make([]string, len(s.Args))
+	for idx, arg := range s.Args {
+		args[idx] = pattern.ReplaceAllStringFunc(arg, func(match string) string {
+			if match == "$$" {
+				return "$"
+			}
+			groups := pattern.FindStringSubmatch(match)
+			return resolved[groups[1]+groups[2]]
+		})
+	}
+	return args, nil
+}
+
+// PlaceholderValues returns the values of the arguments given after '--run name' for the favourites run. Arguments
+// like 'name=value' set the named placeholder, if one of the favourites has a placeholder with the name. All other
+// arguments are the values of the positional placeholders '$1', '$2', ...
+func PlaceholderValues(args []string, favourites ...Favourite) map[string]string {
+	named := map[string]bool{}
+	for _, favourite := range favourites {
+		for _, placeholder := range favourite.Placeholders() {
+			named[placeholder] = true
+		}
+	}
+	values := make(map[string]string, len(args))
+	position := 1
+	for _, arg := range args {
+		if name, value, found := strings.Cut(arg, "="); found && named[name] {
+			values[name] = value
+			continue
+		}
+		values[strconv.Itoa(position)] = arg
+		position++
+	}
+	return values
+}
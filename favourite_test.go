@@ -0,0 +1,274 @@
+package toolsconfig
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavourite_Expand(t *testing.T) {
+	favourite := Favourite{
+		Name:       "deploy",
+		Args:       []string{"deploy", "--env", "{{env}}", "--region", "{{ region }}", "$1", "--price", "$$5", "{{env}}-$2"},
+		Defaults:   map[string]string{"region": "westeurope"},
+		Positional: true,
+	}
+	require.Equal(t, []string{"env", "region", "1", "2"}, favourite.Placeholders())
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		prompt  PlaceholderPrompt
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "values and defaults",
+			values: PlaceholderValues([]string{"env=prod", "app", "blue"}, favourite),
+			want:   []string{"deploy", "--env", "prod", "--region", "westeurope", "app", "--price", "$5", "prod-blue"},
+		},
+		{
+			name:   "values override defaults",
+			values: PlaceholderValues([]string{"region=northeurope", "env=test", "app", "green"}, favourite),
+			want:   []string{"deploy", "--env", "test", "--region", "northeurope", "app", "--price", "$5", "test-green"},
+		},
+		{
+			name:   "prompt",
+			values: PlaceholderValues([]string{"app"}, favourite),
+			prompt: func(name string) (string, error) {
+				return "prompted-" + name, nil
+			},
+			want: []string{"deploy", "--env", "prompted-env", "--region", "westeurope", "app", "--price", "$5", "prompted-env-prompted-2"},
+		},
+		{
+			name:    "prompt error",
+			prompt:  func(name string) (string, error) { return "", fmt.Errorf("no input") },
+			wantErr: "no input",
+		},
+		{
+			name:    "missing",
+			values:  PlaceholderValues([]string{"app"}, favourite),
+			wantErr: "[env, 2]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := favourite.Expand(tt.values, tt.prompt)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, args)
+		})
+	}
+}
+
+func TestFavourite_ExpandLiteralDollar(t *testing.T) {
+	favourite := Favourite{Name: "columns", Args: []string{"logs", "--filter", "{print $1}", "--price", "$$5", "{{env}}"}}
+	require.Equal(t, []string{"env"}, favourite.Placeholders())
+	args, err := favourite.Expand(PlaceholderValues([]string{"env=prod"}, favourite), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"logs", "--filter", "{print $1}", "--price", "$$5", "prod"}, args)
+}
+
+func TestPlaceholderValues(t *testing.T) {
+	favourite := Favourite{Name: "deploy", Args: []string{"{{env}}", "{{url}}", "$1", "$2", "$3"}, Positional: true}
+	require.Equal(t, map[string]string{"env": "prod", "1": "app", "2": "--flag=x", "url": "a=b", "3": "key=value"},
+		PlaceholderValues([]string{"env=prod", "app", "--flag=x", "url=a=b", "key=value"}, favourite))
+	require.Equal(t, map[string]string{"1": "env=prod"}, PlaceholderValues([]string{"env=prod"}))
+}
+
+func TestSortFavourites(t *testing.T) {
+	created := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	favourites := []Favourite{
+		{Name: "b", Created: created, LastRun: created.Add(time.Hour), RunCount: 2},
+		{Name: "c", Created: created.Add(2 * time.Hour)},
+		{Name: "a", Created: created, LastRun: created.Add(3 * time.Hour), RunCount: 2},
+		{Name: "d", Created: created, RunCount: 5},
+	}
+	tests := []struct {
+		order FavouriteOrder
+		want  []string
+	}{
+		{order: FavouriteOrderName, want: []string{"a", "b", "c", "d"}},
+		{order: FavouriteOrderRecent, want: []string{"a", "c", "b", "d"}},
+		{order: FavouriteOrderMostUsed, want: []string{"d", "a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			order, err := ParseFavouriteOrder(string(tt.order))
+			require.NoError(t, err)
+			SortFavourites(favourites, order)
+			names := make([]string, len(favourites))
+			for idx, favourite := range favourites {
+				names[idx] = favourite.Name
+			}
+			require.Equal(t, tt.want, names)
+		})
+	}
+	_, err := ParseFavouriteOrder("size")
+	require.Error(t, err)
+}
+
+func TestFavouriteMetadata(t *testing.T) {
+	var savedConfig = &Config{}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+	configuration, err := NewToolConfiguration()
+	require.NoError(t, err)
+
+	require.NoError(t, configuration.SetFavourite("metatool", Favourite{Name: "deploy", Args: []string{"deploy"}, Description: "Deploy the app"}))
+	created := savedConfig.Favourites["metatool"]["deploy"].Created
+	require.WithinDuration(t, time.Now(), created, time.Minute)
+
+	require.NoError(t, configuration.RecordFavouriteRun("metatool", "deploy"))
+	require.NoError(t, configuration.RecordFavouriteRun("metatool", "deploy"))
+	favourite, err := configuration.GetFavourite("metatool", "deploy")
+	require.NoError(t, err)
+	require.Equal(t, 2, favourite.RunCount)
+	require.Equal(t, "Deploy the app", favourite.Description)
+	require.WithinDuration(t, time.Now(), favourite.LastRun, time.Minute)
+	require.Error(t, configuration.RecordFavouriteRun("metatool", "unknown"))
+
+	require.NoError(t, configuration.SaveFavourite("metatool", "deploy", []string{"deploy", "--force"}))
+	require.Equal(t, created, savedConfig.Favourites["metatool"]["deploy"].Created)
+	require.Equal(t, []string{"deploy", "--force"}, savedConfig.Favourites["metatool"]["deploy"].Args)
+	require.Error(t, configuration.SetFavourite("metatool", Favourite{}))
+
+	require.NoError(t, configuration.SetFavourite("metatool", Favourite{Name: "build", Args: []string{"build"}}))
+	require.Error(t, configuration.RenameFavourite("metatool", "deploy", "build"))
+	require.Error(t, configuration.RenameFavourite("metatool", "unknown", "other"))
+	require.NoError(t, configuration.RenameFavourite("metatool", "deploy", "release"))
+	favourite, err = configuration.GetFavourite("metatool", "release")
+	require.NoError(t, err)
+	require.Equal(t, "release", favourite.Name)
+	require.Equal(t, []string{"deploy", "--force"}, favourite.Args)
+	_, err = configuration.GetFavourite("metatool", "deploy")
+	require.Error(t, err)
+}
+
+func TestFavouriteChain(t *testing.T) {
+	var savedConfig = &Config{
+		Favourites: map[string]map[string]Favourite{
+			"chaintool": {
+				"build":   {Name: "build", Args: []string{"build", "{{env}}"}, Defaults: map[string]string{"env": "dev"}},
+				"deploy":  {Name: "deploy", Args: []string{"deploy", "{{env}}"}},
+				"release": {Name: "release", Steps: []FavouriteStep{{Favourite: "build"}, {Args: []string{"test"}}, {Favourite: "deploy"}}},
+				"prod":    {Name: "prod", Steps: []FavouriteStep{{Favourite: "release"}}, Defaults: map[string]string{"env": "prod"}},
+				"cycle":   {Name: "cycle", Steps: []FavouriteStep{{Favourite: "build"}, {Favourite: "loop"}}},
+				"loop":    {Name: "loop", Steps: []FavouriteStep{{Favourite: "cycle"}}},
+				"missing": {Name: "missing", Steps: []FavouriteStep{{Favourite: "unknown"}}},
+			},
+		},
+	}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+	configuration, err := NewToolConfiguration()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		favourite string
+		wantNames []string
+		wantArgs  [][]string
+		wantErr   string
+	}{
+		{name: "no chain", favourite: "build", wantNames: []string{"build"}, wantArgs: [][]string{{"build", "dev"}}},
+		{name: "chain", favourite: "release", wantNames: []string{"build", "release[2]", "deploy"}, wantArgs: [][]string{{"build", "dev"}, {"test"}, {"deploy", "qa"}}},
+		{name: "nested chain with defaults", favourite: "prod", wantNames: []string{"build", "release[2]", "deploy"}, wantArgs: [][]string{{"build", "prod"}, {"test"}, {"deploy", "prod"}}},
+		{name: "cycle", favourite: "cycle", wantErr: "cyclic favourite chain cycle -> loop -> cycle"},
+		{name: "missing favourite", favourite: "missing", wantErr: "favourite 'unknown'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			favourites, err := configuration.FavouriteChain("chaintool", tt.favourite)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			var args [][]string
+			for _, favourite := range favourites {
+				names = append(names, favourite.Name)
+				expanded, err := favourite.Expand(map[string]string{}, func(name string) (string, error) {
+					return "qa", nil
+				})
+				require.NoError(t, err)
+				args = append(args, expanded)
+			}
+			require.Equal(t, tt.wantNames, names)
+			require.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestRequiresConfirmation(t *testing.T) {
+	var savedConfig = &Config{ConfirmPatterns: []string{`drop table`}}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+	configuration, err := NewToolConfiguration()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		favourite  Favourite
+		args       []string
+		wantReason string
+	}{
+		{name: "no confirmation", favourite: Favourite{Name: "deploy"}, args: []string{"deploy", "--env", "dev"}},
+		{name: "confirm attribute", favourite: Favourite{Name: "deploy", Confirm: true}, args: []string{"deploy"}, wantReason: "favourite 'deploy' requires confirmation"},
+		{name: "default pattern", favourite: Favourite{Name: "deploy"}, args: []string{"deploy", "--env", "prod"}, wantReason: "'--env prod' matches"},
+		{name: "default pattern with equals", favourite: Favourite{Name: "deploy"}, args: []string{"deploy", "--environment=production", "app"}, wantReason: "'--environment=production' matches"},
+		{name: "no match of value prefix", favourite: Favourite{Name: "deploy"}, args: []string{"deploy", "--env", "production-like"}},
+		{name: "config file pattern", favourite: Favourite{Name: "sql"}, args: []string{"sql", "drop table users"}, wantReason: "'drop table' matches confirm pattern 'drop table'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := configuration.RequiresConfirmation(tt.favourite, tt.args)
+			require.NoError(t, err)
+			if tt.wantReason == "" {
+				require.Empty(t, reason)
+				return
+			}
+			require.Contains(t, reason, tt.wantReason)
+		})
+	}
+
+	configuration, err = NewToolConfiguration(ConfirmPatterns(`--cluster[= ]live`))
+	require.NoError(t, err)
+	reason, err := configuration.RequiresConfirmation(Favourite{Name: "deploy"}, []string{"deploy", "--env", "prod"})
+	require.NoError(t, err)
+	require.Empty(t, reason)
+	reason, err = configuration.RequiresConfirmation(Favourite{Name: "deploy"}, []string{"deploy", "--cluster=live"})
+	require.NoError(t, err)
+	require.NotEmpty(t, reason)
+
+	configuration, err = NewToolConfiguration(ConfirmPatterns(`(`))
+	require.NoError(t, err)
+	_, err = configuration.RequiresConfirmation(Favourite{Name: "deploy"}, []string{"deploy"})
+	require.Error(t, err)
+}
@@ -0,0 +1,392 @@
+package toolsconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// FavouriteBundle is a standalone set of favourites of a tool to share them, see ExportFavourites and
+// ImportFavourites.
+type FavouriteBundle struct {
+	Tool       string      `yaml:"tool" json:"tool"`
+	Favourites []Favourite `yaml:"favourites" json:"favourites"`
+}
+
+// ImportStrategy defines how a favourite is imported if a favourite with the same name exists.
+type ImportStrategy string
+
+const (
+	// ImportSkip keeps the existing favourite.
+	ImportSkip ImportStrategy = "skip"
+	// ImportOverwrite replaces the existing favourite.
+	ImportOverwrite ImportStrategy = "overwrite"
+	// ImportRename imports the favourite with a new name like 'deploy-2'.
+	ImportRename ImportStrategy = "rename"
+)
+
+// ParseImportStrategy returns the import strategy with the name, empty is ImportSkip.
+func ParseImportStrategy(value string) (ImportStrategy, error) {
+	switch strategy := ImportStrategy(value); strategy {
+	case "":
+		return ImportSkip, nil
+	case ImportSkip, ImportOverwrite, ImportRename:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown import strategy '%s' (skip, overwrite, rename)", value)
+}
+
+// ImportStatus is the result of the import of a favourite.
+type ImportStatus string
+
+const (
+	ImportAdded       ImportStatus = "added"
+	ImportOverwritten ImportStatus = "overwritten"
+	ImportRenamed     ImportStatus = "renamed"
+	ImportSkipped     ImportStatus = "skipped"
+)
+
+// ImportResult is the result of the import of a favourite of a bundle.
+type ImportResult struct {
+	Name       string
+	ImportedAs string
+	Status     ImportStatus
+}
+
+// CommandFlags returns the flags of the command run by the args of a favourite, nil if unknown. See RedactSecretArgs.
+type CommandFlags func(args []string) *pflag.FlagSet
+
+func (f CommandFlags) of(args []string) *pflag.FlagSet {
+	if f == nil {
+		return nil
+	}
+	return f(args)
+}
+
+// secretFlagPattern matches the names of flags with secret values.
+var secretFlagPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|api-?key|credential)`)
+
+// RedactSecretArgs replaces the values of flags with secret names like '--password' or '--client-secret=..' by the
+// placeholder of the flag name, e.g. '{{password}}'. Values which are placeholders already are kept. The flags are
+// looked up in the flag set to find the long names of shorthands like '-p' and to skip boolean flags, which have no
+// value. Without flag set or for unknown flags, a long flag with a secret name takes the next argument not starting
+// with '-' as value.
+func RedactSecretArgs(args []string, flags *pflag.FlagSet) []string {
+	result := make([]string, len(args))
+	copy(result, args)
+	for idx := 0; idx < len(result); idx++ {
+		arg := result[idx]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+		if !strings.HasPrefix(arg, "--") {
+			idx = redactShorthands(result, idx, flags)
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg[2:], "=")
+		var flag *pflag.Flag
+		if flags != nil {
+			flag = flags.Lookup(name)
+		}
+		secret := flag == nil && IsSecretName(name) || flag != nil && IsSecretFlag(flag)
+		if !secret {
+			continue
+		}
+		placeholder := "{{" + name + "}}"
+		if hasValue {
+			if !isPlaceholder(value) {
+				result[idx] = arg[:len(arg)-len(value)] + placeholder
+			}
+			continue
+		}
+		if flag != nil && flag.NoOptDefVal != "" {
+			continue
+		}
+		if idx+1 < len(result) && (flag != nil || !strings.HasPrefix(result[idx+1], "-")) {
+			idx++
+			if !isPlaceholder(result[idx]) {
+				result[idx] = placeholder
+			}
+		}
+	}
+	return result
+}
+
+// redactShorthands redacts the value of a secret flag in the shorthands of result[idx], e.g. '-p secret' or '-vpsecret'.
+// Returns the index of the last argument used by the shorthands.
+func redactShorthands(result []string, idx int, flags *pflag.FlagSet) int {
+	if flags == nil {
+		return idx
+	}
+	shorthands := result[idx][1:]
+	for pos := 0; pos < len(shorthands); pos++ {
+		flag := flags.ShorthandLookup(shorthands[pos : pos+1])
+		if flag == nil {
+			return idx
+		}
+		value := strings.TrimPrefix(shorthands[pos+1:], "=")
+		if isBoolFlag(flag) || flag.NoOptDefVal != "" && value == "" {
+			continue
+		}
+		placeholder := "{{" + flag.Name + "}}"
+		if !IsSecretFlag(flag) {
+			if value == "" {
+				idx++
+			}
+			return idx
+		}
+		if value != "" {
+			if !isPlaceholder(value) {
+				result[idx] = result[idx][:len(result[idx])-len(value)] + placeholder
+			}
+			return idx
+		}
+		if idx+1 < len(result) {
+			idx++
+			if !isPlaceholder(result[idx]) {
+				result[idx] = placeholder
+			}
+		}
+		return idx
+	}
+	return idx
+}
+
+// IsSecretFlag returns true if the flag has a secret name like '--password' and a value, boolean and count flags have
+// no value.
+func IsSecretFlag(flag *pflag.Flag) bool {
+	return IsSecretName(flag.Name) && !isBoolFlag(flag) && flag.Value.Type() != "count"
+}
+
+// IsSecretName returns true for the names of flags and placeholders with secret values, e.g. 'password' or
+// 'client-secret'.
+func IsSecretName(name string) bool {
+	return secretFlagPattern.MatchString(name)
+}
+
+func isBoolFlag(flag *pflag.Flag) bool {
+	value, ok := flag.Value.(interface{ IsBoolFlag() bool })
+	return ok && value.IsBoolFlag()
+}
+
+func isPlaceholder(value string) bool {
+	return placeholderPattern.FindString(value) == value
+}
+
+// shareable returns a copy of the favourite without personal data and with redacted secret args, see
+// RedactSecretArgs.
+func (s Favourite) shareable(flags CommandFlags) Favourite {
+	shared := Favourite{
+		Name:            s.Name,
+		ContinueOnError: s.ContinueOnError,
+		Positional:      s.Positional,
+		Description:     s.Description,
+		Confirm:         s.Confirm,
+	}
+	if len(s.Args) > 0 {
+		shared.Args = RedactSecretArgs(s.Args, flags.of(s.Args))
+	}
+	for _, step := range s.Steps {
+		if len(step.Args) > 0 {
+			step.Args = RedactSecretArgs(step.Args, flags.of(step.Args))
+		}
+		shared.Steps = append(shared.Steps, step)
+	}
+	if len(s.Defaults) > 0 {
+		shared.Defaults = make(map[string]string, len(s.Defaults))
+		for key, value := range s.Defaults {
+			if secretFlagPattern.MatchString(key) {
+				continue
+			}
+			shared.Defaults[key] = value
+		}
+	}
+	return shared
+}
+
+// ExportFavourites returns a bundle with the favourites of the tool, all favourites if no names are given. The
+// favourites referenced by exported chains are exported too. Run statistics are not exported and the values of
+// secret flags like '--password' are replaced by placeholders, the flags of the commands are used to find them (may be
+// nil, see RedactSecretArgs).
+func (c *ToolConfiguration) ExportFavourites(tool string, flags CommandFlags, names ...string) (*FavouriteBundle, error) {
+	if len(names) == 0 {
+		for _, favourite := range c.GetFavourites(tool) {
+			names = append(names, favourite.Name)
+		}
+	}
+	bundle := &FavouriteBundle{Tool: tool, Favourites: []Favourite{}}
+	exported := make(map[string]bool, len(names))
+	for len(names) > 0 {
+		name := names[0]
+		names = names[1:]
+		if exported[name] {
+			continue
+		}
+		favourite, err := c.GetFavourite(tool, name)
+		if err != nil {
+			return nil, wrapErr(errNotFound, "favourite '"+name+"'")
+		}
+		exported[name] = true
+		bundle.Favourites = append(bundle.Favourites, favourite.shareable(flags))
+		for _, step := range favourite.Steps {
+			if step.Favourite != "" {
+				names = append(names, step.Favourite)
+			}
+		}
+	}
+	SortFavourites(bundle.Favourites, FavouriteOrderName)
+	return bundle, nil
+}
+
+// Marshal encodes the bundle in the format 'yaml' or 'json'.
+func (b FavouriteBundle) Marshal(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "", ConfigFormat:
+		var buffer bytes.Buffer
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(b); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown format '%s' (yaml, json)", format)
+}
+
+// ParseFavouriteBundle decodes a bundle in yaml or json format.
+func ParseFavouriteBundle(data []byte) (*FavouriteBundle, error) {
+	var bundle FavouriteBundle
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid favourite bundle: %w", err)
+	}
+	names := make(map[string]bool, len(bundle.Favourites))
+	for _, favourite := range bundle.Favourites {
+		if favourite.Name == "" {
+			return nil, fmt.Errorf("invalid favourite bundle: favourite name missing")
+		}
+		if names[favourite.Name] {
+			return nil, fmt.Errorf("invalid favourite bundle: duplicate favourite '%s'", favourite.Name)
+		}
+		names[favourite.Name] = true
+	}
+	return &bundle, nil
+}
+
+// ImportFavourites adds the favourites of the bundle to the favourites of the tool in the config file. Existing
+// favourites with the same name are handled by the strategy. References of imported chains to renamed favourites of
+// the bundle are renamed too. The values of secret flags are replaced by placeholders like on export.
+func (c *ToolConfiguration) ImportFavourites(tool string, bundle FavouriteBundle, strategy ImportStrategy, flags CommandFlags) ([]ImportResult, error) {
+	if bundle.Tool != "" && bundle.Tool != tool {
+		return nil, wrapErr(fmt.Errorf("favourites of tool '%s' can not be imported into '%s'", bundle.Tool, tool))
+	}
+	if _, err := ParseImportStrategy(string(strategy)); err != nil {
+		return nil, wrapErr(err)
+	}
+	taken := func(name string) bool {
+		_, err := c.GetFavourite(tool, name)
+		return err == nil
+	}
+	results := make([]ImportResult, len(bundle.Favourites))
+	renamed := map[string]string{}
+	// renamed favourites must not use the name of another favourite of the bundle
+	reserved := make(map[string]bool, len(bundle.Favourites))
+	for _, favourite := range bundle.Favourites {
+		reserved[favourite.Name] = true
+	}
+	for idx, favourite := range bundle.Favourites {
+		result := ImportResult{Name: favourite.Name, ImportedAs: favourite.Name, Status: ImportAdded}
+		if taken(favourite.Name) {
+			switch strategy {
+			case ImportOverwrite:
+				result.Status = ImportOverwritten
+			case ImportRename:
+				result.Status = ImportRenamed
+				for counter := 2; taken(result.ImportedAs) || reserved[result.ImportedAs]; counter++ {
+					result.ImportedAs = fmt.Sprintf("%s-%d", favourite.Name, counter)
+				}
+				renamed[favourite.Name] = result.ImportedAs
+			default:
+				result.Status = ImportSkipped
+			}
+		}
+		reserved[result.ImportedAs] = true
+		results[idx] = result
+	}
+
+	if c.config.Favourites == nil {
+		c.config.Favourites = make(map[string]map[string]Favourite, 1)
+	}
+	if c.config.Favourites[tool] == nil {
+		c.config.Favourites[tool] = make(map[string]Favourite, len(bundle.Favourites))
+	}
+	now := time.Now()
+	for idx, favourite := range bundle.Favourites {
+		if results[idx].Status == ImportSkipped {
+			continue
+		}
+		favourite = favourite.shareable(flags)
+		favourite.Name = results[idx].ImportedAs
+		favourite.Created = now
+		for stepIdx, step := range favourite.Steps {
+			if newName, ok := renamed[step.Favourite]; ok {
+				favourite.Steps[stepIdx].Favourite = newName
+			}
+		}
+		c.config.Favourites[tool][favourite.Name] = favourite
+	}
+	err := saveConfiguration(c.config)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	return results, nil
+}
+
+// readSharedFavourites reads the favourites of the shared files, the first file wins for favourites with the same
+// name.
+func readSharedFavourites(files []string) (map[string]map[string]Favourite, error) {
+	shared := map[string]map[string]Favourite{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		bundle, err := ParseFavouriteBundle(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if bundle.Tool == "" {
+			return nil, fmt.Errorf("%s: invalid favourite bundle: tool missing", file)
+		}
+		if shared[bundle.Tool] == nil {
+			shared[bundle.Tool] = make(map[string]Favourite, len(bundle.Favourites))
+		}
+		for _, favourite := range bundle.Favourites {
+			if _, exists := shared[bundle.Tool][favourite.Name]; exists {
+				continue
+			}
+			favourite.SharedFile = file
+			shared[bundle.Tool][favourite.Name] = favourite
+		}
+	}
+	return shared, nil
+}
@@ -0,0 +1,178 @@
+package toolsconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactSecretArgs(t *testing.T) {
+	flags := pflag.NewFlagSet("login", pflag.ContinueOnError)
+	flags.StringP("password", "p", "", "")
+	flags.StringP("env", "e", "", "")
+	flags.BoolP("verbose", "v", false, "")
+	flags.Bool("use-token", false, "")
+	flags.String("token", "", "")
+
+	tests := []struct {
+		name  string
+		args  []string
+		flags *pflag.FlagSet
+		want  []string
+	}{
+		{name: "no secrets", args: []string{"deploy", "--env", "prod"}, want: []string{"deploy", "--env", "prod"}},
+		{name: "separate value", args: []string{"login", "--password", "s3cret", "--user", "me"}, want: []string{"login", "--password", "{{password}}", "--user", "me"}},
+		{name: "inline value", args: []string{"login", "--client-secret=s3cret"}, want: []string{"login", "--client-secret={{client-secret}}"}},
+		{name: "placeholder kept", args: []string{"login", "--token", "{{token}}", "--api-key={{key}}"}, want: []string{"login", "--token", "{{token}}", "--api-key={{key}}"}},
+		{name: "boolean flag", args: []string{"login", "--no-token-cache", "--verbose"}, want: []string{"login", "--no-token-cache", "--verbose"}},
+		{name: "boolean secret flag", args: []string{"login", "--use-token", "myapp"}, flags: flags, want: []string{"login", "--use-token", "myapp"}},
+		{name: "boolean secret flag with value", args: []string{"login", "--use-token=true", "myapp"}, flags: flags, want: []string{"login", "--use-token=true", "myapp"}},
+		{name: "value like flag", args: []string{"login", "--token", "-secret"}, flags: flags, want: []string{"login", "--token", "{{token}}"}},
+		{name: "shorthand", args: []string{"login", "-p", "s3cret", "-e", "prod"}, flags: flags, want: []string{"login", "-p", "{{password}}", "-e", "prod"}},
+		{name: "combined shorthands", args: []string{"login", "-vps3cret", "-ve", "prod"}, flags: flags, want: []string{"login", "-vp{{password}}", "-ve", "prod"}},
+		{name: "shorthand with equals", args: []string{"login", "-p=s3cret"}, flags: flags, want: []string{"login", "-p={{password}}"}},
+		{name: "value of other flag", args: []string{"login", "-e", "--password", "--password", "s3cret"}, flags: flags, want: []string{"login", "-e", "--password", "--password", "{{password}}"}},
+		{name: "positional args", args: []string{"login", "--", "--password", "s3cret"}, flags: flags, want: []string{"login", "--", "--password", "s3cret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, RedactSecretArgs(tt.args, tt.flags))
+		})
+	}
+}
+
+func TestFavouriteBundle(t *testing.T) {
+	var savedConfig = &Config{
+		Favourites: map[string]map[string]Favourite{
+			"bundletool": {
+				"build":   {Name: "build", Args: []string{"build"}, Created: time.Now(), RunCount: 3},
+				"login":   {Name: "login", Args: []string{"login", "--password", "s3cret"}, Defaults: map[string]string{"password": "s3cret", "user": "me"}},
+				"release": {Name: "release", Steps: []FavouriteStep{{Favourite: "build"}, {Favourite: "login"}}, Description: "Release"},
+			},
+		},
+	}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+	configuration, err := NewToolConfiguration()
+	require.NoError(t, err)
+
+	bundle, err := configuration.ExportFavourites("bundletool", nil, "release")
+	require.NoError(t, err)
+	require.Equal(t, &FavouriteBundle{Tool: "bundletool", Favourites: []Favourite{
+		{Name: "build", Args: []string{"build"}},
+		{Name: "login", Args: []string{"login", "--password", "{{password}}"}, Defaults: map[string]string{"user": "me"}},
+		{Name: "release", Steps: []FavouriteStep{{Favourite: "build"}, {Favourite: "login"}}, Description: "Release"},
+	}}, bundle)
+	_, err = configuration.ExportFavourites("bundletool", nil, "unknown")
+	require.Error(t, err)
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			data, err := bundle.Marshal(format)
+			require.NoError(t, err)
+			require.NotContains(t, string(data), "s3cret")
+			parsed, err := ParseFavouriteBundle(data)
+			require.NoError(t, err)
+			require.Equal(t, bundle, parsed)
+		})
+	}
+	_, err = bundle.Marshal("xml")
+	require.Error(t, err)
+	_, err = ParseFavouriteBundle([]byte("tool: x\nfavourites:\n  - name: a\n  - name: a\n"))
+	require.Error(t, err)
+
+	tests := []struct {
+		name     string
+		strategy ImportStrategy
+		want     []ImportResult
+		steps    []FavouriteStep
+	}{
+		{name: "skip", strategy: ImportSkip, want: []ImportResult{
+			{Name: "build", ImportedAs: "build", Status: ImportSkipped},
+			{Name: "login", ImportedAs: "login", Status: ImportSkipped},
+			{Name: "release", ImportedAs: "release", Status: ImportSkipped},
+		}, steps: []FavouriteStep{{Favourite: "build"}, {Favourite: "login"}}},
+		{name: "rename", strategy: ImportRename, want: []ImportResult{
+			{Name: "build", ImportedAs: "build-2", Status: ImportRenamed},
+			{Name: "login", ImportedAs: "login-2", Status: ImportRenamed},
+			{Name: "release", ImportedAs: "release-2", Status: ImportRenamed},
+		}, steps: []FavouriteStep{{Favourite: "build-2"}, {Favourite: "login-2"}}},
+		{name: "overwrite", strategy: ImportOverwrite, want: []ImportResult{
+			{Name: "build", ImportedAs: "build", Status: ImportOverwritten},
+			{Name: "login", ImportedAs: "login", Status: ImportOverwritten},
+			{Name: "release", ImportedAs: "release", Status: ImportOverwritten},
+		}, steps: []FavouriteStep{{Favourite: "build"}, {Favourite: "login"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := configuration.ImportFavourites("bundletool", *bundle, tt.strategy, nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, results)
+			imported := savedConfig.Favourites["bundletool"][tt.want[2].ImportedAs]
+			require.Equal(t, tt.steps, imported.Steps)
+		})
+	}
+	require.Zero(t, savedConfig.Favourites["bundletool"]["build"].RunCount)
+	_, err = configuration.ImportFavourites("othertool", *bundle, ImportSkip, nil)
+	require.Error(t, err)
+}
+
+func TestSharedFavourites(t *testing.T) {
+	var savedConfig = &Config{
+		Favourites: map[string]map[string]Favourite{
+			"sharedtool": {"build": {Name: "build", Args: []string{"build", "--local"}}},
+			"emptytool":  {},
+			"othertool":  {"test": {Name: "test", Args: []string{"test"}}},
+		},
+	}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+	sharedFile := filepath.Join(t.TempDir(), "favourites.yaml")
+	require.NoError(t, os.WriteFile(sharedFile, []byte(`tool: sharedtool
+favourites:
+  - name: build
+    args: [build]
+  - name: deploy
+    args: [deploy, "{{env}}"]
+`), 0644))
+	configuration, err := NewToolConfiguration(SharedFavourites(sharedFile, filepath.Join(t.TempDir(), "missing.yaml")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"othertool", "sharedtool"}, configuration.FavouriteTools())
+
+	favourites := configuration.GetFavourites("sharedtool")
+	require.Len(t, favourites, 2)
+	require.Equal(t, []string{"build", "--local"}, favourites[0].Args)
+	require.Empty(t, favourites[0].SharedFile)
+	require.Equal(t, sharedFile, favourites[1].SharedFile)
+
+	favourite, err := configuration.GetFavourite("sharedtool", "deploy")
+	require.NoError(t, err)
+	require.Equal(t, []string{"deploy", "{{env}}"}, favourite.Args)
+	require.NoError(t, configuration.RecordFavouriteRun("sharedtool", "deploy"))
+	require.Error(t, configuration.RemoveFavourite("sharedtool", "deploy"))
+	require.Error(t, configuration.RenameFavourite("sharedtool", "deploy", "ship"))
+	require.NoError(t, configuration.RemoveFavourite("sharedtool", "build"))
+	favourite, err = configuration.GetFavourite("sharedtool", "build")
+	require.NoError(t, err)
+	require.Equal(t, sharedFile, favourite.SharedFile)
+
+	require.NoError(t, os.WriteFile(sharedFile, []byte("favourites: []\n"), 0644))
+	_, err = NewToolConfiguration(SharedFavourites(sharedFile))
+	require.Error(t, err)
+}
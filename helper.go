@@ -1,9 +1,12 @@
 package toolsconfig
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -70,3 +73,86 @@ func toEnvironmentKey(key string, additionalElements ...string) string {
 	prefix = strings.ReplaceAll(prefix, "-", "_")
 	return strings.ToUpper(strings.Join(append([]string{prefix}, additionalElements...), "_"))
 }
+
+// yamlFieldNames returns the yaml names of the exported fields of a struct type, including inlined structs.
+func yamlFieldNames(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && (field.Anonymous || (len(tag) > 1 && tag[1] == "inline")) {
+			names = append(names, yamlFieldNames(field.Type)...)
+			continue
+		}
+		names = append(names, yamlFieldName(field))
+	}
+	return names
+}
+
+// yamlFieldName returns the yaml name of a struct field.
+func yamlFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func splitScopes(scopes string) []string {
+	return strings.FieldsFunc(scopes, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+}
+
+// ConfigFilePath returns the path of the configuration file set with ConfigFileLocation.
+func ConfigFilePath() (string, error) {
+	if configDirectory == nil || configFile == nil {
+		return "", fmt.Errorf(`configuration file location not set (Call toolconfig.ConfigFileLocation("dir", "filename"))`)
+	}
+	file, err := configFileName(*configDirectory, *configFile)
+	if err != nil {
+		return "", err
+	}
+	return *file, nil
+}
+
+// configSiblingFile returns the path of a file in the directory of the configuration file.
+func configSiblingFile(name string) (string, error) {
+	file, err := ConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(path.Dir(file), name), nil
+}
+
+// WriteFileAtomic writes the content to a temporary file in the target directory and renames it, so readers never see
+// a partially written file. The file gets 0600 permissions and missing directories are created with 0700.
+func WriteFileAtomic(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,121 @@
+package toolsconfig
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// variablePattern matches '${name}' and the escaped '$${'.
+var variablePattern = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+
+// interpolate replaces the variables '${name}' of the value. A name is looked up in this order:
+// * '<kind>.<identifier>.<field>' the field of another entry, e.g. '${servers.artifactory.username}'
+// * the 'variables' section of the config file
+// * the environment variables
+// Unknown variables are kept as they are. Use '$${' for a literal '${'.
+func (c *ToolConfiguration) interpolate(value string, stack []string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	var err error
+	result := variablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if err != nil {
+			return match
+		}
+		if match == "$${" {
+			return "${"
+		}
+		replacement, found, lookupErr := c.lookupVariable(strings.TrimSpace(match[2:len(match)-1]), stack)
+		if !found {
+			return match
+		}
+		err = lookupErr
+		return replacement
+	})
+	return result, err
+}
+
+// lookupVariable returns the value of the variable and whether the variable exists.
+func (c *ToolConfiguration) lookupVariable(name string, stack []string) (string, bool, error) {
+	for _, element := range stack {
+		if element == name {
+			return "", true, wrapErr(fmt.Errorf("cyclic variable reference %s -> %s", strings.Join(stack, " -> "), name))
+		}
+	}
+	stack = append(stack, name)
+
+	segments := strings.Split(name, ".")
+	if len(segments) >= 3 {
+		if entry, ok := c.config.entryByKind(segments[0], strings.Join(segments[1:len(segments)-1], ".")); ok {
+			field := segments[len(segments)-1]
+			value, found := entryField(entry, field)
+			if !found {
+				return "", true, wrapErr(fmt.Errorf("unknown field in variable '%s'", name))
+			}
+			// the secret reference of the original value of a secret field
+			if _, _, isReference := c.secretReference(value); isReference && isSecretEntryField(entry, field) {
+				resolved, err := c.resolveSecret(value)
+				return resolved, true, err
+			}
+			resolved, err := c.interpolate(value, stack)
+			return resolved, true, err
+		}
+	}
+	if value, ok := c.config.Variables[name]; ok {
+		resolved, err := c.interpolate(value, stack)
+		return resolved, true, err
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+	return "", false, nil
+}
+
+// entryByKind returns the entry of the given kind (the yaml name of the section) and identifier.
+func (c Config) entryByKind(kind, id string) (interface{}, bool) {
+	var entry interface{}
+	var err error
+	switch kind {
+	case "servers":
+		entry, _, err = c.serverCredential(id)
+	case "azureSubscriptions":
+		entry, _, err = c.azureSubscriptionCredential(id)
+	case "generics":
+		entry, _, err = c.genericCredential(id)
+	case "databases":
+		entry, _, err = c.databaseCredential(id)
+	case "kubernetes":
+		entry, _, err = c.kubernetesCredential(id)
+	case "oauth2":
+		entry, _, err = c.oauth2Credential(id)
+	default:
+		return nil, false
+	}
+	return entry, err == nil
+}
+
+// entryField returns the value of the field with the given yaml name, string slices are joined by spaces. The named
+// fields of generic credentials are found, too.
+func entryField(entry interface{}, field string) (string, bool) {
+	value := reflect.Indirect(reflect.ValueOf(entry))
+	for i := 0; i < value.NumField(); i++ {
+		if !value.Type().Field(i).Anonymous && yamlFieldName(value.Type().Field(i)) == field {
+			if value.Field(i).Kind() == reflect.Slice && value.Field(i).Type().Elem().Kind() == reflect.String {
+				elements := make([]string, value.Field(i).Len())
+				for j := range elements {
+					elements[j] = value.Field(i).Index(j).String()
+				}
+				return strings.Join(elements, " "), true
+			}
+			return fmt.Sprint(value.Field(i).Interface()), true
+		}
+	}
+	if generic, ok := entry.(*GenericCredential); ok {
+		fieldValue, found := generic.Fields[field]
+		return fieldValue, found
+	}
+	return "", false
+}
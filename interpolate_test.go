@@ -0,0 +1,81 @@
+package toolsconfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolation(t *testing.T) {
+	require.NoError(t, os.Setenv("TOOLSCONFIG_TEST_USER", "env-user"))
+	defer os.Unsetenv("TOOLSCONFIG_TEST_USER")
+	var savedConfig = &Config{
+		Variables: map[string]string{
+			"domain":  "example.com",
+			"apiHost": "api.${domain}",
+			"loopA":   "${loopB}",
+			"loopB":   "${loopA}",
+			"command": "cmd:echo injected",
+		},
+		Servers: []ServerCredential{
+			{URL: "artifactory", Username: "${TOOLSCONFIG_TEST_USER}", Password: "env:TOOLSCONFIG_TEST_USER"},
+			{URL: "maven", Username: "${servers.artifactory.username}", Password: "${servers.artifactory.password}"},
+			{URL: "escaped", Username: "$${literal}", Password: "secret"},
+			{URL: "cycle", Username: "user", Password: "${loopA}"},
+			{URL: "injected", Username: "user", Password: "${command}"},
+			{URL: "dollar", Username: "user", Password: "pa${ss"},
+		},
+		Generic: []GenericCredential{
+			{Key: "api", Value: "${generics.api.clientID}:${unknownVariable}", Fields: map[string]string{"clientID": "client@${apiHost}"}},
+		},
+	}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+	configuration, err := NewToolConfiguration()
+	require.NoError(t, err)
+
+	t.Run("VariablesAndEntries", func(t *testing.T) {
+		server, err := configuration.GetServerCredentials("maven")
+		require.NoError(t, err)
+		require.Equal(t, "env-user", server.Username)
+		// the referenced value is a secret reference itself
+		require.Equal(t, "env-user", server.Password)
+	})
+
+	t.Run("EscapedAndCycle", func(t *testing.T) {
+		_, err := configuration.GetServerCredentials("cycle")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cyclic variable reference loopA -> loopB -> loopA")
+		server, err := configuration.GetServerCredentials("escaped")
+		require.NoError(t, err)
+		require.Equal(t, "${literal}", server.Username)
+	})
+
+	t.Run("UnknownVariable", func(t *testing.T) {
+		generic, err := configuration.GetGenericCredentials("api")
+		require.NoError(t, err)
+		require.Equal(t, "client@api.example.com:${unknownVariable}", generic.Value)
+		require.Equal(t, "client@api.example.com", configuration.GetGenericField("api", "clientID"))
+		server, err := configuration.GetServerCredentials("dollar")
+		require.NoError(t, err)
+		require.Equal(t, "pa${ss", server.Password)
+	})
+
+	t.Run("InterpolatedSecretReference", func(t *testing.T) {
+		server, err := configuration.GetServerCredentials("injected")
+		require.NoError(t, err)
+		require.Equal(t, "cmd:echo injected", server.Password)
+	})
+
+	t.Run("RawTemplatesSaved", func(t *testing.T) {
+		require.Equal(t, "${servers.artifactory.username}", savedConfig.Servers[1].Username)
+		require.Equal(t, "client@${apiHost}", savedConfig.Generic[0].Fields["clientID"])
+	})
+}
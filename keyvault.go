@@ -0,0 +1,131 @@
+package toolsconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultKeyVaultEndpoint = "https://{vault}.vault.azure.net"
+	keyVaultResource        = "https://vault.azure.net"
+	keyVaultAPIVersion      = "7.4"
+)
+
+// KeyVaultConfig configures the resolution of 'keyvault://vault-name/secret-name' secret references.
+type KeyVaultConfig struct {
+	// Subscription is the name or ID of the subscription credentials used to access the key vaults.
+	// Default is the default azure subscription.
+	Subscription string `yaml:"subscription,omitempty"`
+	// Endpoint is the URL of the key vaults, '{vault}' is replaced by the vault name. Default 'https://{vault}.vault.azure.net'.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// AuthorityHost is the authority host used to get the access token. Default see DefaultAzureAuthorityHost.
+	AuthorityHost string `yaml:"authorityHost,omitempty"`
+}
+
+// resolveKeyVaultReference resolves references like 'keyvault://vault-name/secret-name'. A specific version of the
+// secret can be requested with 'keyvault://vault-name/secret-name/version', another subscription with the query
+// parameter 'subscription', e.g. 'keyvault://vault-name/secret-name?subscription=prod'.
+func (c *ToolConfiguration) resolveKeyVaultReference(reference string) (string, error) {
+	if value, ok := c.keyVaultSecrets[reference]; ok {
+		return value, nil
+	}
+	if !strings.HasPrefix(reference, "//") {
+		return "", fmt.Errorf("invalid key vault reference, expected 'keyvault://vault-name/secret-name'")
+	}
+	parsed, err := url.Parse("keyvault:" + reference)
+	if err != nil {
+		return "", err
+	}
+	secretPath := strings.Trim(parsed.Path, "/")
+	if parsed.Host == "" || secretPath == "" {
+		return "", fmt.Errorf("invalid key vault reference, expected 'keyvault://vault-name/secret-name'")
+	}
+
+	config := KeyVaultConfig{}
+	if c.config.KeyVault != nil {
+		config = *c.config.KeyVault
+	}
+	subscription := parsed.Query().Get("subscription")
+	if subscription == "" {
+		subscription = config.Subscription
+	}
+	provider, err := c.keyVaultTokenProvider(subscription, config)
+	if err != nil {
+		return "", err
+	}
+	token, err := provider.Token(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = defaultKeyVaultEndpoint
+	}
+	endpoint = strings.ReplaceAll(endpoint, "{vault}", parsed.Host)
+	request, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/secrets/"+secretPath+"?api-version="+keyVaultAPIVersion, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	var secret struct {
+		Value string `json:"value"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(body, &secret)
+	if response.StatusCode != http.StatusOK {
+		message := fmt.Sprintf("key vault request for '%s' failed with status %d", reference, response.StatusCode)
+		if secret.Error != nil {
+			message += ": " + secret.Error.Code + " - " + secret.Error.Message
+		}
+		return "", errors.New(message)
+	}
+	c.keyVaultSecrets[reference] = secret.Value
+	return secret.Value, nil
+}
+
+// keyVaultTokenProvider returns the token provider of the subscription. The subscription credentials are resolved
+// themselves, a reference to a key vault accessed with the same subscription is reported as cycle.
+func (c *ToolConfiguration) keyVaultTokenProvider(subscription string, config KeyVaultConfig) (*AzureTokenProvider, error) {
+	if provider, ok := c.keyVaultTokens[subscription]; ok {
+		return provider, nil
+	}
+	if c.keyVaultResolving[subscription] {
+		return nil, fmt.Errorf("cyclic key vault reference in subscription credentials '%s'", subscription)
+	}
+	c.keyVaultResolving[subscription] = true
+	defer delete(c.keyVaultResolving, subscription)
+
+	credential, err := c.GetAzureSubscriptionCredentials(subscription)
+	if err != nil {
+		return nil, err
+	}
+	var options []TokenSourceOption
+	if config.AuthorityHost != "" {
+		options = append(options, WithAuthorityHost(config.AuthorityHost))
+	}
+	provider, err := credential.TokenProvider(keyVaultResource, options...)
+	if err != nil {
+		return nil, err
+	}
+	c.keyVaultTokens[subscription] = provider
+	return provider, nil
+}
@@ -0,0 +1,78 @@
+package toolsconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyVaultReferences(t *testing.T) {
+	var secretRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/reader-tenant/oauth2/v2.0/token":
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "https://vault.azure.net/.default", r.PostForm.Get("scope"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "kv-token", "expires_in": 3600})
+		case "/myvault/secrets/deploy-secret":
+			secretRequests++
+			require.Equal(t, "Bearer kv-token", r.Header.Get("Authorization"))
+			require.Equal(t, keyVaultAPIVersion, r.URL.Query().Get("api-version"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"value": "rotated-secret"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"code": "SecretNotFound", "message": "secret not found"}})
+		}
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), nil, 0600))
+	ConfigFileLocation(configDir, "config.yaml")
+	defer ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return &Config{
+			KeyVault: &KeyVaultConfig{Subscription: "kv-reader", Endpoint: server.URL + "/{vault}", AuthorityHost: server.URL},
+			AzureSubscriptions: []AzureSubscriptionCredential{
+				{Name: "kv-reader", SubscriptionID: "reader-id", TenantID: "reader-tenant", ClientID: "reader-client", ClientSecret: "reader-secret"},
+				{Name: "deploy", SubscriptionID: "deploy-id", TenantID: "deploy-tenant", ClientID: "deploy-client", ClientSecret: "keyvault://myvault/deploy-secret"},
+				{Name: "missing", SubscriptionID: "missing-id", TenantID: "deploy-tenant", ClientID: "deploy-client", ClientSecret: "keyvault://myvault/unknown"},
+				{Name: "self", SubscriptionID: "self-id", TenantID: "self-tenant", ClientID: "self-client", ClientSecret: "keyvault://myvault/deploy-secret?subscription=self"},
+			},
+		}
+	}
+	saveConfiguration = func(config *Config) error {
+		return nil
+	}
+
+	configuration, err := NewToolConfiguration(RequiredSubscription("deploy"))
+	require.NoError(t, err)
+
+	t.Run("Resolve", func(t *testing.T) {
+		subscription, err := configuration.GetAzureSubscriptionCredentials("deploy")
+		require.NoError(t, err)
+		require.Equal(t, "rotated-secret", subscription.ClientSecret)
+		_, err = configuration.GetAzureSubscriptionCredentials("deploy")
+		require.NoError(t, err)
+		require.Equal(t, 1, secretRequests)
+		require.FileExists(t, filepath.Join(configDir, TokenCacheFile))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := configuration.GetAzureSubscriptionCredentials("missing")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "SecretNotFound")
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		_, err := configuration.GetAzureSubscriptionCredentials("self")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cyclic key vault reference")
+	})
+}
@@ -0,0 +1,227 @@
+package toolsconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kubeconfig renders a minimal kubeconfig containing one cluster, user and context named after the credential.
+func (c KubernetesCredential) Kubeconfig() ([]byte, error) {
+	if !c.valid() {
+		return nil, wrapErr(fmt.Errorf("incomplete kubernetes credential"), "kubernetes '"+c.Name+"'")
+	}
+	kubeconfig := map[string]interface{}{
+		"apiVersion":      "v1",
+		"kind":            "Config",
+		"preferences":     map[string]interface{}{},
+		"clusters":        []interface{}{c.kubeconfigCluster()},
+		"users":           []interface{}{c.kubeconfigUser()},
+		"contexts":        []interface{}{c.kubeconfigContext()},
+		"current-context": c.Name,
+	}
+	return yaml.Marshal(kubeconfig)
+}
+
+// WriteTempKubeconfig writes the kubeconfig of the credential to a new temporary file with 0600 permissions and returns
+// its path. The caller is responsible for removing the file.
+func (c KubernetesCredential) WriteTempKubeconfig() (string, error) {
+	content, err := c.Kubeconfig()
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp("", "kubeconfig-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if err := file.Chmod(0600); err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	if _, err := file.Write(content); err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// MergeKubeconfig adds or replaces the cluster, user and context of the credential in the kubeconfig file at the given
+// path. All other entries of the file are kept with their comments and order. If the path is empty the default
+// kubeconfig location is used, a symlinked kubeconfig is updated at its target.
+// The current context is only changed if setCurrentContext is true or no current context is set.
+func (c KubernetesCredential) MergeKubeconfig(path string, setCurrentContext bool) error {
+	if !c.valid() {
+		return wrapErr(fmt.Errorf("incomplete kubernetes credential"), "kubernetes '"+c.Name+"'")
+	}
+	if path == "" {
+		defaultPath, err := DefaultKubeconfigPath()
+		if err != nil {
+			return err
+		}
+		path = defaultPath
+	}
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	var document yaml.Node
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return fmt.Errorf("could not parse kubeconfig '%s': %w", path, err)
+		}
+	}
+	if document.Kind == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	kubeconfig := document.Content[0]
+	if document.Kind != yaml.DocumentNode || kubeconfig.Kind != yaml.MappingNode {
+		return fmt.Errorf("could not parse kubeconfig '%s': not a mapping", path)
+	}
+	if mappingValue(kubeconfig, "apiVersion") == nil {
+		setMappingValue(kubeconfig, "apiVersion", "v1")
+	}
+	if mappingValue(kubeconfig, "kind") == nil {
+		setMappingValue(kubeconfig, "kind", "Config")
+	}
+	entries := []struct {
+		key   string
+		entry map[string]interface{}
+	}{
+		{key: "clusters", entry: c.kubeconfigCluster()},
+		{key: "users", entry: c.kubeconfigUser()},
+		{key: "contexts", entry: c.kubeconfigContext()},
+	}
+	for _, entry := range entries {
+		if err := upsertNamed(kubeconfig, entry.key, entry.entry); err != nil {
+			return fmt.Errorf("could not merge kubeconfig '%s': %w", path, err)
+		}
+	}
+	if current := mappingValue(kubeconfig, "current-context"); setCurrentContext || current == nil || current.Value == "" {
+		setMappingValue(kubeconfig, "current-context", c.Name)
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, buffer.Bytes())
+}
+
+// DefaultKubeconfigPath returns the first file of the KUBECONFIG environment variable or '~/.kube/config'.
+func DefaultKubeconfigPath() (string, error) {
+	if files := filepath.SplitList(os.Getenv("KUBECONFIG")); len(files) > 0 && files[0] != "" {
+		return files[0], nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kube", "config"), nil
+}
+
+func (c KubernetesCredential) kubeconfigCluster() map[string]interface{} {
+	cluster := map[string]interface{}{
+		"server": c.Server,
+	}
+	if c.CertificateAuthorityData != "" {
+		cluster["certificate-authority-data"] = c.CertificateAuthorityData
+	}
+	return map[string]interface{}{"name": c.Name, "cluster": cluster}
+}
+
+func (c KubernetesCredential) kubeconfigUser() map[string]interface{} {
+	user := map[string]interface{}{}
+	if c.Token != "" {
+		user["token"] = c.Token
+	} else {
+		user["client-certificate-data"] = c.ClientCertificateData
+		user["client-key-data"] = c.ClientKeyData
+	}
+	return map[string]interface{}{"name": c.Name, "user": user}
+}
+
+func (c KubernetesCredential) kubeconfigContext() map[string]interface{} {
+	context := map[string]interface{}{
+		"cluster": c.Name,
+		"user":    c.Name,
+	}
+	if c.Namespace != "" {
+		context["namespace"] = c.Namespace
+	}
+	return map[string]interface{}{"name": c.Name, "context": context}
+}
+
+// upsertNamed replaces the entry with the same name in the kubeconfig list of the key or appends it.
+func upsertNamed(kubeconfig *yaml.Node, key string, entry map[string]interface{}) error {
+	var node yaml.Node
+	if err := node.Encode(entry); err != nil {
+		return err
+	}
+	// the name first like the entries written by kubectl
+	for idx := 2; idx+1 < len(node.Content); idx += 2 {
+		if node.Content[idx].Value == "name" {
+			name := node.Content[idx : idx+2]
+			node.Content = append(append([]*yaml.Node{name[0], name[1]}, node.Content[:idx]...), node.Content[idx+2:]...)
+			break
+		}
+	}
+	list := mappingValue(kubeconfig, key)
+	if list == nil || list.Tag == "!!null" {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setMappingNode(kubeconfig, key, list)
+	}
+	if list.Kind != yaml.SequenceNode {
+		return fmt.Errorf("'%s' is not a list", key)
+	}
+	if len(list.Content) == 0 {
+		list.Style = 0
+	}
+	for idx, existing := range list.Content {
+		if name := mappingValue(existing, "name"); name != nil && name.Value == entry["name"] {
+			list.Content[idx] = &node
+			return nil
+		}
+	}
+	list.Content = append(list.Content, &node)
+	return nil
+}
+
+// mappingValue returns the value of the key of a yaml mapping node or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		if mapping.Content[idx].Value == key {
+			return mapping.Content[idx+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets the string value of the key of a yaml mapping node.
+func setMappingValue(mapping *yaml.Node, key, value string) {
+	setMappingNode(mapping, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+// setMappingNode sets the value of the key of a yaml mapping node, the key is appended if missing.
+func setMappingNode(mapping *yaml.Node, key string, value *yaml.Node) {
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		if mapping.Content[idx].Value == key {
+			value.LineComment = mapping.Content[idx+1].LineComment
+			mapping.Content[idx+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
@@ -0,0 +1,125 @@
+package toolsconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var testKubernetesCredential = KubernetesCredential{
+	Name:                     "prod-aks",
+	Server:                   "https://prod-aks.example.com:443",
+	CertificateAuthorityData: "Q0EtREFUQQ==",
+	Token:                    "cluster-token",
+	Namespace:                "deployments",
+}
+
+func TestKubernetesCredential_Kubeconfig(t *testing.T) {
+	content, err := testKubernetesCredential.Kubeconfig()
+	require.NoError(t, err)
+
+	var kubeconfig struct {
+		CurrentContext string `yaml:"current-context"`
+		Clusters       []struct {
+			Name    string `yaml:"name"`
+			Cluster struct {
+				Server                   string `yaml:"server"`
+				CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			} `yaml:"cluster"`
+		} `yaml:"clusters"`
+		Users []struct {
+			Name string `yaml:"name"`
+			User struct {
+				Token string `yaml:"token"`
+			} `yaml:"user"`
+		} `yaml:"users"`
+		Contexts []struct {
+			Name    string `yaml:"name"`
+			Context struct {
+				Cluster   string `yaml:"cluster"`
+				User      string `yaml:"user"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"context"`
+		} `yaml:"contexts"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &kubeconfig))
+	require.Equal(t, "prod-aks", kubeconfig.CurrentContext)
+	require.Len(t, kubeconfig.Clusters, 1)
+	require.Equal(t, "https://prod-aks.example.com:443", kubeconfig.Clusters[0].Cluster.Server)
+	require.Equal(t, "Q0EtREFUQQ==", kubeconfig.Clusters[0].Cluster.CertificateAuthorityData)
+	require.Len(t, kubeconfig.Users, 1)
+	require.Equal(t, "cluster-token", kubeconfig.Users[0].User.Token)
+	require.Len(t, kubeconfig.Contexts, 1)
+	require.Equal(t, "deployments", kubeconfig.Contexts[0].Context.Namespace)
+
+	_, err = KubernetesCredential{Name: "incomplete", Server: "https://example.com"}.Kubeconfig()
+	require.Error(t, err)
+}
+
+func TestKubernetesCredential_WriteTempKubeconfig(t *testing.T) {
+	file, err := testKubernetesCredential.WriteTempKubeconfig()
+	require.NoError(t, err)
+	defer os.Remove(file)
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestKubernetesCredential_MergeKubeconfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".kube", "config")
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0700))
+	existing := `# managed by hand
+apiVersion: v1
+kind: Config
+current-context: dev # the default context
+custom: value
+clusters:
+  - name: dev
+    cluster:
+      server: https://dev.example.com
+  - name: prod-aks
+    cluster:
+      server: https://old.example.com
+users:
+  - name: dev
+    user:
+      token: dev-token
+contexts:
+  - name: dev
+    context:
+      cluster: dev
+      user: dev
+`
+	target := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(target, []byte(existing), 0600))
+	require.NoError(t, os.Symlink(target, file))
+
+	require.NoError(t, testKubernetesCredential.MergeKubeconfig(file, false))
+
+	info, err := os.Lstat(file)
+	require.NoError(t, err)
+	require.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(content), "# managed by hand\napiVersion: v1\nkind: Config\ncurrent-context: dev # the default context\ncustom: value\n"))
+	var kubeconfig map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(content, &kubeconfig))
+	require.Equal(t, "dev", kubeconfig["current-context"])
+	clusters := kubeconfig["clusters"].([]interface{})
+	require.Len(t, clusters, 2)
+	prodCluster := clusters[1].(map[string]interface{})["cluster"].(map[string]interface{})
+	require.Equal(t, "https://prod-aks.example.com:443", prodCluster["server"])
+	require.Len(t, kubeconfig["users"], 2)
+	require.Len(t, kubeconfig["contexts"], 2)
+
+	require.NoError(t, testKubernetesCredential.MergeKubeconfig(file, true))
+	content, err = os.ReadFile(file)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(content, &kubeconfig))
+	require.Equal(t, "prod-aks", kubeconfig["current-context"])
+	require.Len(t, kubeconfig["contexts"], 2)
+}
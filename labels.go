@@ -0,0 +1,130 @@
+package toolsconfig
+
+// Labels are the optional aliases and tags of a credential entry. Aliases are alternative identifiers used by the
+// lookups (e.g. other urls of the same server or short names of a subscription), tags group entries for bulk
+// operations (see FindServers, FindAzureSubscriptions, ...).
+type Labels struct {
+	Aliases []string `yaml:"aliases,flow,omitempty"`
+	Tags    []string `yaml:"tags,flow,omitempty"`
+}
+
+func (l Labels) hasAlias(alias string) bool {
+	for _, value := range l.Aliases {
+		if value == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag returns true if the entry is tagged with the tag.
+func (l Labels) HasTag(tag string) bool {
+	for _, value := range l.Tags {
+		if value == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// FindServers returns all server credentials of the config file tagged with the tag. The credentials are resolved like
+// the credentials returned by GetServerCredentials.
+func (c *ToolConfiguration) FindServers(tag string) ([]ServerCredential, error) {
+	var result []ServerCredential
+	for _, server := range c.config.Servers {
+		if !server.HasTag(tag) {
+			continue
+		}
+		resolved, err := c.GetServerCredentials(server.URL)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *resolved)
+	}
+	return result, nil
+}
+
+// FindAzureSubscriptions returns all azure subscription credentials of the config file tagged with the tag. The credentials are resolved like
+// the credentials returned by GetAzureSubscriptionCredentials.
+func (c *ToolConfiguration) FindAzureSubscriptions(tag string) ([]AzureSubscriptionCredential, error) {
+	var result []AzureSubscriptionCredential
+	for _, subscription := range c.config.AzureSubscriptions {
+		if !subscription.HasTag(tag) {
+			continue
+		}
+		resolved, err := c.GetAzureSubscriptionCredentials(subscription.Name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *resolved)
+	}
+	return result, nil
+}
+
+// FindGenerics returns all generic credentials of the config file tagged with the tag. The credentials are resolved like
+// the credentials returned by GetGenericCredentials.
+func (c *ToolConfiguration) FindGenerics(tag string) ([]GenericCredential, error) {
+	var result []GenericCredential
+	for _, generic := range c.config.Generic {
+		if !generic.HasTag(tag) {
+			continue
+		}
+		resolved, err := c.GetGenericCredentials(generic.Key)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *resolved)
+	}
+	return result, nil
+}
+
+// FindDatabases returns all database credentials of the config file tagged with the tag. The credentials are resolved like
+// the credentials returned by GetDatabaseCredentials.
+func (c *ToolConfiguration) FindDatabases(tag string) ([]DatabaseCredential, error) {
+	var result []DatabaseCredential
+	for _, database := range c.config.Databases {
+		if !database.HasTag(tag) {
+			continue
+		}
+		resolved, err := c.GetDatabaseCredentials(database.Name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *resolved)
+	}
+	return result, nil
+}
+
+// FindKubernetes returns all kubernetes credentials of the config file tagged with the tag. The credentials are resolved like
+// the credentials returned by GetKubernetesCredentials.
+func (c *ToolConfiguration) FindKubernetes(tag string) ([]KubernetesCredential, error) {
+	var result []KubernetesCredential
+	for _, cluster := range c.config.Kubernetes {
+		if !cluster.HasTag(tag) {
+			continue
+		}
+		resolved, err := c.GetKubernetesCredentials(cluster.Name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *resolved)
+	}
+	return result, nil
+}
+
+// FindOAuth2 returns all oauth2 credentials of the config file tagged with the tag. The credentials are resolved like
+// the credentials returned by GetOAuth2Credentials.
+func (c *ToolConfiguration) FindOAuth2(tag string) ([]OAuth2Credential, error) {
+	var result []OAuth2Credential
+	for _, client := range c.config.OAuth2 {
+		if !client.HasTag(tag) {
+			continue
+		}
+		resolved, err := c.GetOAuth2Credentials(client.Name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *resolved)
+	}
+	return result, nil
+}
@@ -0,0 +1,147 @@
+package toolsconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const labelsConfig = `
+variables:
+  gitUser: git-user
+servers:
+  - url: https://artifactory.example.com/artifactory
+    username: user
+    password: password
+    aliases: [ docker.example.com, "https://maven.example.com" ]
+    tags: [ ci, artifactory ]
+  - url: https://git.example.com
+    username: ${gitUser}
+    password: env:LABELS_GIT_PASSWORD
+    tags: [ ci ]
+azureSubscriptions:
+  - name: production-subscription
+    subscriptionID: 00000000-0000-0000-0000-000000000001
+    tenantID: tenant
+    clientID: client
+    clientSecret: secret
+    aliases: [ prod ]
+    tags: [ production ]
+generics:
+  - key: labels-generic
+    value: value
+    aliases: [ short ]
+databases:
+  - name: orders
+    driver: postgres
+    host: localhost
+    username: user
+    password: password
+    aliases: [ db ]
+    tags: [ production ]
+`
+
+func TestLabels(t *testing.T) {
+	var savedConfig Config
+	require.NoError(t, yaml.Unmarshal([]byte(labelsConfig), &savedConfig))
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return &savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = *config
+		return nil
+	}
+	configuration, err := NewToolConfiguration()
+	require.NoError(t, err)
+
+	t.Run("lookup by alias", func(t *testing.T) {
+		server, err := configuration.GetServerCredentials("docker.example.com")
+		require.NoError(t, err)
+		require.Equal(t, "user", server.Username)
+		server, err = configuration.GetServerCredentials("https://maven.example.com/releases")
+		require.NoError(t, err)
+		require.Equal(t, "user", server.Username)
+		match, err := configuration.ExplainServerMatch("maven.example.com/releases")
+		require.NoError(t, err)
+		require.Equal(t, "https://maven.example.com", match.Entry)
+		require.Equal(t, MatchHost, match.Rule)
+
+		subscription, err := configuration.GetAzureSubscriptionCredentials("prod")
+		require.NoError(t, err)
+		require.Equal(t, "production-subscription", subscription.Name)
+		require.Equal(t, "value", configuration.GetGeneric("short"))
+		database, err := configuration.GetDatabaseCredentials("db")
+		require.NoError(t, err)
+		require.Equal(t, "orders", database.Name)
+		_, err = configuration.GetDatabaseCredentials("unknown")
+		require.Error(t, err)
+	})
+
+	t.Run("find by tag", func(t *testing.T) {
+		t.Setenv("LABELS_GIT_PASSWORD", "git-password")
+		servers, err := configuration.FindServers("ci")
+		require.NoError(t, err)
+		require.Len(t, servers, 2)
+		require.Equal(t, "git-user", servers[1].Username)
+		require.Equal(t, "git-password", servers[1].Password)
+		servers, err = configuration.FindServers("artifactory")
+		require.NoError(t, err)
+		require.Len(t, servers, 1)
+		servers, err = configuration.FindServers("production")
+		require.NoError(t, err)
+		require.Empty(t, servers)
+		subscriptions, err := configuration.FindAzureSubscriptions("production")
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 1)
+		databases, err := configuration.FindDatabases("production")
+		require.NoError(t, err)
+		require.Len(t, databases, 1)
+		generics, err := configuration.FindGenerics("production")
+		require.NoError(t, err)
+		require.Empty(t, generics)
+	})
+
+	t.Run("find by tag unresolved", func(t *testing.T) {
+		_, err := configuration.FindServers("ci")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "LABELS_GIT_PASSWORD")
+	})
+
+	t.Run("set labels", func(t *testing.T) {
+		require.NoError(t, configuration.SetDatabaseCredentials(DatabaseCredential{Name: "orders", Driver: "mysql", Host: "db", Username: "u", Password: "p",
+			Labels: Labels{Aliases: []string{"orders-db"}}}))
+		require.Equal(t, []string{"orders-db"}, savedConfig.Databases[0].Aliases)
+		require.Empty(t, savedConfig.Databases[0].Tags)
+		require.NoError(t, configuration.SetServerCredentials(ServerCredential{URL: "https://artifactory.example.com/artifactory", Username: "new", Password: "new",
+			Labels: Labels{Tags: []string{"ci"}}}))
+		require.Equal(t, []string{"ci"}, savedConfig.Servers[0].Tags)
+		require.NoError(t, configuration.SetServerCredentials(ServerCredential{URL: "https://artifactory.example.com/artifactory", Username: "new", Password: "new"}))
+		require.Empty(t, savedConfig.Servers[0].Tags)
+
+		servers := len(savedConfig.Servers)
+		require.NoError(t, configuration.SetServerCredentials(ServerCredential{URL: "docker.example.com", Username: "docker", Password: "docker"}))
+		require.Len(t, savedConfig.Servers, servers+1)
+		require.Equal(t, "https://artifactory.example.com/artifactory", savedConfig.Servers[0].URL)
+		require.Equal(t, "new", savedConfig.Servers[0].Username)
+		require.NoError(t, configuration.SetServerCredentials(ServerCredential{URL: "https://git.example.com/", Username: "git-user", Password: "git-password",
+			Labels: Labels{Tags: []string{"ci"}}}))
+		require.Len(t, savedConfig.Servers, servers+1)
+		require.Equal(t, "https://git.example.com", savedConfig.Servers[1].URL)
+		require.NoError(t, configuration.SetAzureSubscriptionCredentials(AzureSubscriptionCredential{Name: "prod", SubscriptionID: "other"}))
+		require.Len(t, savedConfig.AzureSubscriptions, 2)
+		require.Equal(t, "production-subscription", savedConfig.AzureSubscriptions[0].Name)
+		require.Equal(t, "00000000-0000-0000-0000-000000000001", savedConfig.AzureSubscriptions[0].SubscriptionID)
+		require.NoError(t, configuration.SetGenericCredentials(GenericCredential{Key: "short", Value: "other"}))
+		require.Len(t, savedConfig.Generic, 2)
+		require.Equal(t, "value", savedConfig.Generic[0].Value)
+		require.NoError(t, configuration.SetDatabaseCredentials(DatabaseCredential{Name: "orders-db", Driver: "mysql"}))
+		require.Len(t, savedConfig.Databases, 2)
+		require.Equal(t, "orders", savedConfig.Databases[0].Name)
+
+		content, err := yaml.Marshal(savedConfig.Servers[1])
+		require.NoError(t, err)
+		require.Equal(t, "url: https://git.example.com\nusername: git-user\npassword: git-password\ntags: [ci]\n", string(content))
+	})
+}
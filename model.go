@@ -3,7 +3,11 @@ package toolsconfig
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 var _ Configuration = &ToolConfiguration{}
@@ -13,6 +17,21 @@ type ToolConfiguration struct {
 	servers            map[string]*ServerCredential
 	azureSubscriptions map[string]*AzureSubscriptionCredential
 	generics           map[string]*GenericCredential
+	databases          map[string]*DatabaseCredential
+	kubernetes         map[string]*KubernetesCredential
+	oauth2             map[string]*OAuth2Credential
+	customs            map[string]interface{}
+	secretResolvers    map[string]SecretResolver
+	vault              *vaultClient
+	keyVaultTokens     map[string]*AzureTokenProvider
+	keyVaultResolving  map[string]bool
+	keyVaultSecrets    map[string]string
+	envKey             envKeyFunc
+	wellKnownEnv       bool
+	mergeEnv           bool
+	configFile         string
+	sharedFavourites   map[string]map[string]Favourite
+	confirmPatterns    []string
 	configReader       func() (*Configuration, error)
 }
 
@@ -21,13 +40,23 @@ type Config struct {
 	Servers                  []ServerCredential              `yaml:"servers"`
 	AzureSubscriptions       []AzureSubscriptionCredential   `yaml:"azureSubscriptions"`
 	Generic                  []GenericCredential             `yaml:"generics"`
+	Databases                []DatabaseCredential            `yaml:"databases,omitempty"`
+	Kubernetes               []KubernetesCredential          `yaml:"kubernetes,omitempty"`
+	OAuth2                   []OAuth2Credential              `yaml:"oauth2,omitempty"`
+	Custom                   map[string]map[string]yaml.Node `yaml:"custom,omitempty"`
+	Vault                    *VaultConfig                    `yaml:"vault,omitempty"`
+	KeyVault                 *KeyVaultConfig                 `yaml:"keyVault,omitempty"`
+	Variables                map[string]string               `yaml:"variables,omitempty"`
 	Favourites               map[string]map[string]Favourite `yaml:"favourites"`
+	// ConfirmPatterns are additional patterns of favourites which must be confirmed, see RequiresConfirmation.
+	ConfirmPatterns []string `yaml:"confirmPatterns,omitempty"`
 }
 
 type ServerCredential struct {
 	URL      string `yaml:"url"`
 	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	Password string `yaml:"password" secret:"true"`
+	Labels   `yaml:",inline"`
 }
 
 type AzureSubscriptionCredential struct {
@@ -35,27 +64,104 @@ type AzureSubscriptionCredential struct {
 	SubscriptionID string `yaml:"subscriptionID"`
 	TenantID       string `yaml:"tenantID"`
 	ClientID       string `yaml:"clientID"`
-	ClientSecret   string `yaml:"clientSecret"`
+	ClientSecret   string `yaml:"clientSecret" secret:"true"`
+	Labels         `yaml:",inline"`
 }
 
+// GenericCredential is a simple key/value pair. Optionally it holds named fields, e.g. a client ID and a token of
+// the same system.
 type GenericCredential struct {
-	Key   string `yaml:"key"`
-	Value string `yaml:"value"`
+	Key    string            `yaml:"key"`
+	Value  string            `yaml:"value" secret:"true"`
+	Fields map[string]string `yaml:"fields,omitempty" secret:"true"`
+	Labels `yaml:",inline"`
+}
+
+// DatabaseCredential holds the connection settings of a database. Use DSN to render the connection string for the driver.
+type DatabaseCredential struct {
+	Name     string            `yaml:"name"`
+	Driver   string            `yaml:"driver"`
+	Host     string            `yaml:"host"`
+	Port     int               `yaml:"port,omitempty"`
+	Database string            `yaml:"database"`
+	Username string            `yaml:"username"`
+	Password string            `yaml:"password" secret:"true"`
+	TLSMode  string            `yaml:"tlsMode,omitempty"`
+	Params   map[string]string `yaml:"params,omitempty"`
+	Labels   `yaml:",inline"`
+}
+
+// KubernetesCredential holds the access to a kubernetes cluster. Either a token or a client certificate and key is required.
+// The certificate and key data are base64 encoded PEM blocks like in a kubeconfig file.
+type KubernetesCredential struct {
+	Name                     string `yaml:"name"`
+	Server                   string `yaml:"server"`
+	CertificateAuthorityData string `yaml:"certificateAuthorityData"`
+	Token                    string `yaml:"token,omitempty" secret:"true"`
+	ClientCertificateData    string `yaml:"clientCertificateData,omitempty"`
+	ClientKeyData            string `yaml:"clientKeyData,omitempty" secret:"true"`
+	Namespace                string `yaml:"namespace"`
+	Labels                   `yaml:",inline"`
+}
+
+// OAuth2Credential holds an OAuth2 client. Use NewTokenSource to get access tokens with the client credentials or
+// the refresh token flow.
+type OAuth2Credential struct {
+	Name         string   `yaml:"name"`
+	TokenURL     string   `yaml:"tokenURL"`
+	ClientID     string   `yaml:"clientID"`
+	ClientSecret string   `yaml:"clientSecret" secret:"true"`
+	Scopes       []string `yaml:"scopes,flow,omitempty"`
+	RefreshToken string   `yaml:"refreshToken,omitempty" secret:"true"`
+	Labels       `yaml:",inline"`
 }
 
+// Favourite is a saved command line. The args can contain placeholders '{{name}}' and, if positional, '$1', see Expand.
+// A favourite with steps is a chain running other favourites or inline args in sequence, see FavouriteChain.
 type Favourite struct {
-	Name string   `yaml:"name"`
-	Args []string `yaml:"args,flow"`
+	Name string   `yaml:"name" json:"name"`
+	Args []string `yaml:"args,flow,omitempty" json:"args,omitempty"`
+	// Steps of a chain.
+	Steps []FavouriteStep `yaml:"steps,omitempty" json:"steps,omitempty"`
+	// ContinueOnError runs the remaining steps of a chain after a failed step.
+	ContinueOnError bool `yaml:"continueOnError,omitempty" json:"continueOnError,omitempty"`
+	// Positional enables the positional placeholders '$1', '$2', ... and the escaped '$$', otherwise a '$' is literal.
+	// Inline steps of a chain use the setting of the chain.
+	Positional bool `yaml:"positional,omitempty" json:"positional,omitempty"`
+	// Defaults are the values of placeholders used if no value is given.
+	Defaults    map[string]string `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
+	// Confirm asks for confirmation before the favourite runs.
+	Confirm bool `yaml:"confirm,omitempty" json:"confirm,omitempty"`
+	// Created, LastRun and RunCount are personal and not part of exported favourites.
+	Created  time.Time `yaml:"created,omitempty" json:"-"`
+	LastRun  time.Time `yaml:"lastRun,omitempty" json:"-"`
+	RunCount int       `yaml:"runCount,omitempty" json:"-"`
+	// SharedFile is the read-only file of a shared favourite, see SharedFavourites.
+	SharedFile string `yaml:"-" json:"-"`
+}
+
+// FavouriteStep is a step of a favourite chain, either the name of another favourite or inline args.
+type FavouriteStep struct {
+	Favourite string   `yaml:"favourite,omitempty" json:"favourite,omitempty"`
+	Args      []string `yaml:"args,flow,omitempty" json:"args,omitempty"`
 }
 
 func (s Favourite) String() string {
+	if s.IsChain() {
+		steps := make([]string, len(s.Steps))
+		for idx, step := range s.Steps {
+			steps[idx] = step.String()
+		}
+		return fmt.Sprintf("%s - %s", s.Name, strings.Join(steps, " -> "))
+	}
 	return fmt.Sprintf("%s - '%s'", s.Name, strings.Join(s.Args, " "))
 }
 
 func (c *Config) merge(required *Config) bool {
 	var dirty bool
 	for _, server := range required.Servers {
-		_, _, err := c.serverCredential(server.URL)
+		_, _, _, err := c.matchServer(server.URL)
 		if err != nil {
 			c.Servers = append(c.Servers, server)
 			dirty = true
@@ -69,30 +175,136 @@ func (c *Config) merge(required *Config) bool {
 		}
 	}
 	for _, generic := range required.Generic {
-		_, _, err := c.genericCredential(generic.Key)
+		_, index, err := c.genericCredential(generic.Key)
 		if err != nil {
 			c.Generic = append(c.Generic, generic)
 			dirty = true
+			continue
+		}
+		for field := range generic.Fields {
+			if _, ok := c.Generic[*index].Fields[field]; !ok {
+				if c.Generic[*index].Fields == nil {
+					c.Generic[*index].Fields = make(map[string]string, len(generic.Fields))
+				}
+				c.Generic[*index].Fields[field] = ""
+				dirty = true
+			}
+		}
+	}
+	for _, database := range required.Databases {
+		_, _, err := c.databaseCredential(database.Name)
+		if err != nil {
+			c.Databases = append(c.Databases, database)
+			dirty = true
+		}
+	}
+	for _, cluster := range required.Kubernetes {
+		_, _, err := c.kubernetesCredential(cluster.Name)
+		if err != nil {
+			c.Kubernetes = append(c.Kubernetes, cluster)
+			dirty = true
+		}
+	}
+	for _, client := range required.OAuth2 {
+		_, _, err := c.oauth2Credential(client.Name)
+		if err != nil {
+			c.OAuth2 = append(c.OAuth2, client)
+			dirty = true
+		}
+	}
+	for typeName, entries := range required.Custom {
+		for name, node := range entries {
+			if _, err := c.customCredential(typeName, name); err != nil {
+				if c.Custom == nil {
+					c.Custom = make(map[string]map[string]yaml.Node, 1)
+				}
+				if c.Custom[typeName] == nil {
+					c.Custom[typeName] = make(map[string]yaml.Node, 1)
+				}
+				c.Custom[typeName][name] = node
+				dirty = true
+			}
 		}
 	}
 	return dirty
 }
 
+// entryIndex returns the index of the entry of the given kind (the yaml name of the section) with exactly the
+// identifier, or -1. Servers are identified by the normalized url. Aliases are not matched, so writes never change
+// another entry.
+func (c Config) entryIndex(kind, id string) int {
+	switch kind {
+	case "servers":
+		for index, server := range c.Servers {
+			if SameServerURL(server.URL, id) {
+				return index
+			}
+		}
+	case "azureSubscriptions":
+		for index, subscription := range c.AzureSubscriptions {
+			if subscription.Name == id {
+				return index
+			}
+		}
+	case "generics":
+		for index, generic := range c.Generic {
+			if generic.Key == id {
+				return index
+			}
+		}
+	case "databases":
+		for index, database := range c.Databases {
+			if database.Name == id {
+				return index
+			}
+		}
+	case "kubernetes":
+		for index, cluster := range c.Kubernetes {
+			if cluster.Name == id {
+				return index
+			}
+		}
+	case "oauth2":
+		for index, client := range c.OAuth2 {
+			if client.Name == id {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// serverCredential returns the entry with the same normalized url. See matchServer to find the best matching entry.
 func (c Config) serverCredential(url string) (*ServerCredential, *int, error) {
+	requested := parseServerURL(url)
 	for index, server := range c.Servers {
-		if server.URL == url {
+		if server.URL == url || parseServerURL(server.URL).equal(requested) {
 			return &server, &index, nil
 		}
 	}
+	for index, server := range c.Servers {
+		for _, alias := range server.Aliases {
+			if parseServerURL(alias).equal(requested) {
+				return &server, &index, nil
+			}
+		}
+	}
 	return nil, nil, wrapErr(errNotFound, "server '"+url+"'")
 }
 
+// azureSubscriptionCredential returns the entry with the name or subscription ID. Aliases are used if no entry has
+// the name or ID.
 func (c Config) azureSubscriptionCredential(nameOrID string) (*AzureSubscriptionCredential, *int, error) {
 	for index, subscription := range c.AzureSubscriptions {
 		if subscription.Name == nameOrID || subscription.SubscriptionID == nameOrID {
 			return &subscription, &index, nil
 		}
 	}
+	for index, subscription := range c.AzureSubscriptions {
+		if subscription.hasAlias(nameOrID) {
+			return &subscription, &index, nil
+		}
+	}
 	return nil, nil, wrapErr(errNotFound, "subscription '"+nameOrID+"'")
 }
 
@@ -102,16 +314,67 @@ func (c Config) genericCredential(key string) (*GenericCredential, *int, error)
 			return &generic, &index, nil
 		}
 	}
+	for index, generic := range c.Generic {
+		if generic.hasAlias(key) {
+			return &generic, &index, nil
+		}
+	}
 	return nil, nil, wrapErr(errNotFound, "generic '"+key+"'")
 }
 
+func (c Config) databaseCredential(name string) (*DatabaseCredential, *int, error) {
+	for index, database := range c.Databases {
+		if database.Name == name {
+			return &database, &index, nil
+		}
+	}
+	for index, database := range c.Databases {
+		if database.hasAlias(name) {
+			return &database, &index, nil
+		}
+	}
+	return nil, nil, wrapErr(errNotFound, "database '"+name+"'")
+}
+
+func (c Config) kubernetesCredential(name string) (*KubernetesCredential, *int, error) {
+	for index, cluster := range c.Kubernetes {
+		if cluster.Name == name {
+			return &cluster, &index, nil
+		}
+	}
+	for index, cluster := range c.Kubernetes {
+		if cluster.hasAlias(name) {
+			return &cluster, &index, nil
+		}
+	}
+	return nil, nil, wrapErr(errNotFound, "kubernetes '"+name+"'")
+}
+
+func (c Config) oauth2Credential(name string) (*OAuth2Credential, *int, error) {
+	for index, client := range c.OAuth2 {
+		if client.Name == name {
+			return &client, &index, nil
+		}
+	}
+	for index, client := range c.OAuth2 {
+		if client.hasAlias(name) {
+			return &client, &index, nil
+		}
+	}
+	return nil, nil, wrapErr(errNotFound, "oauth2 '"+name+"'")
+}
+
 func (c ServerCredential) valid() bool {
 	return c.Username != "" && c.Password != ""
 }
 
 func (c ServerCredential) FromEnv(url string) *ServerCredential {
-	username := os.Getenv(toEnvironmentKey(url, "username"))
-	password := os.Getenv(toEnvironmentKey(url, "password"))
+	return c.fromEnv(url, toEnvironmentKey)
+}
+
+func (c ServerCredential) fromEnv(url string, envKey envKeyFunc) *ServerCredential {
+	username := os.Getenv(envKey(url, "username"))
+	password := os.Getenv(envKey(url, "password"))
 	result := ServerCredential{
 		URL:      url,
 		Username: username,
@@ -128,10 +391,14 @@ func (c AzureSubscriptionCredential) valid() bool {
 }
 
 func (c AzureSubscriptionCredential) FromEnv(name string) *AzureSubscriptionCredential {
-	subscriptionId := os.Getenv(toEnvironmentKey(name, "subscriptionId"))
-	tenantId := os.Getenv(toEnvironmentKey(name, "tenantId"))
-	clientId := os.Getenv(toEnvironmentKey(name, "clientId"))
-	clientSecret := os.Getenv(toEnvironmentKey(name, "clientSecret"))
+	return c.fromEnv(name, toEnvironmentKey)
+}
+
+func (c AzureSubscriptionCredential) fromEnv(name string, envKey envKeyFunc) *AzureSubscriptionCredential {
+	subscriptionId := os.Getenv(envKey(name, "subscriptionID"))
+	tenantId := os.Getenv(envKey(name, "tenantID"))
+	clientId := os.Getenv(envKey(name, "clientID"))
+	clientSecret := os.Getenv(envKey(name, "clientSecret"))
 	result := AzureSubscriptionCredential{
 		Name:           name,
 		SubscriptionID: subscriptionId,
@@ -146,10 +413,64 @@ func (c AzureSubscriptionCredential) FromEnv(name string) *AzureSubscriptionCred
 }
 
 func (c GenericCredential) valid() bool {
-	return c.Value != ""
+	if c.Value != "" {
+		return true
+	}
+	for _, value := range c.Fields {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// genericValueField is the name of the value of generic credentials in environment variables, named fields must not
+// use it.
+const genericValueField = "value"
+
+// validFields returns an error if a named field collides with the value.
+func (c GenericCredential) validFields() error {
+	if _, ok := c.Fields[genericValueField]; ok {
+		return wrapErr(errReservedGenericField, "generic '"+c.Key+"'")
+	}
+	return nil
 }
+
+// withKnownFieldsFromEnv returns a copy of the credential, the fields of the credential are overridden by environment
+// variables.
+func (c GenericCredential) withKnownFieldsFromEnv(envKey envKeyFunc) *GenericCredential {
+	fields := make([]string, 0, len(c.Fields))
+	for field := range c.Fields {
+		fields = append(fields, field)
+	}
+	result := c.withFieldsFromEnv(envKey, fields...)
+	return &result
+}
+
+// withFieldsFromEnv returns a copy of the credential, the given fields are overridden by environment variables.
+func (c GenericCredential) withFieldsFromEnv(envKey envKeyFunc, fields ...string) GenericCredential {
+	result := c
+	result.Fields = make(map[string]string, len(c.Fields))
+	for field, value := range c.Fields {
+		result.Fields[field] = value
+	}
+	for _, field := range fields {
+		if value, ok := os.LookupEnv(envKey(c.Key, field)); ok {
+			result.Fields[field] = value
+		}
+	}
+	if len(result.Fields) == 0 {
+		result.Fields = c.Fields
+	}
+	return result
+}
+
 func (c GenericCredential) FromEnv(key string) *GenericCredential {
-	value := os.Getenv(toEnvironmentKey(key, "value"))
+	return c.fromEnv(key, toEnvironmentKey)
+}
+
+func (c GenericCredential) fromEnv(key string, envKey envKeyFunc) *GenericCredential {
+	value := os.Getenv(envKey(key, "value"))
 	result := GenericCredential{
 		Key:   key,
 		Value: value,
@@ -159,3 +480,84 @@ func (c GenericCredential) FromEnv(key string) *GenericCredential {
 	}
 	return nil
 }
+
+func (c DatabaseCredential) valid() bool {
+	return c.Driver != "" && c.Host != "" && c.Username != "" && c.Password != ""
+}
+
+func (c DatabaseCredential) FromEnv(name string) *DatabaseCredential {
+	return c.fromEnv(name, toEnvironmentKey)
+}
+
+func (c DatabaseCredential) fromEnv(name string, envKey envKeyFunc) *DatabaseCredential {
+	var port int
+	if value := os.Getenv(envKey(name, "port")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil
+		}
+		port = parsed
+	}
+	result := DatabaseCredential{
+		Name:     name,
+		Driver:   os.Getenv(envKey(name, "driver")),
+		Host:     os.Getenv(envKey(name, "host")),
+		Port:     port,
+		Database: os.Getenv(envKey(name, "database")),
+		Username: os.Getenv(envKey(name, "username")),
+		Password: os.Getenv(envKey(name, "password")),
+		TLSMode:  os.Getenv(envKey(name, "tlsMode")),
+	}
+	if result.valid() {
+		return &result
+	}
+	return nil
+}
+
+func (c KubernetesCredential) valid() bool {
+	return c.Server != "" && (c.Token != "" || (c.ClientCertificateData != "" && c.ClientKeyData != ""))
+}
+
+func (c KubernetesCredential) FromEnv(name string) *KubernetesCredential {
+	return c.fromEnv(name, toEnvironmentKey)
+}
+
+func (c KubernetesCredential) fromEnv(name string, envKey envKeyFunc) *KubernetesCredential {
+	result := KubernetesCredential{
+		Name:                     name,
+		Server:                   os.Getenv(envKey(name, "server")),
+		CertificateAuthorityData: os.Getenv(envKey(name, "certificateAuthorityData")),
+		Token:                    os.Getenv(envKey(name, "token")),
+		ClientCertificateData:    os.Getenv(envKey(name, "clientCertificateData")),
+		ClientKeyData:            os.Getenv(envKey(name, "clientKeyData")),
+		Namespace:                os.Getenv(envKey(name, "namespace")),
+	}
+	if result.valid() {
+		return &result
+	}
+	return nil
+}
+
+func (c OAuth2Credential) valid() bool {
+	return c.TokenURL != "" && c.ClientID != "" && (c.ClientSecret != "" || c.RefreshToken != "")
+}
+
+// FromEnv reads the OAuth2 client from the environment. Scopes are separated by spaces or commas.
+func (c OAuth2Credential) FromEnv(name string) *OAuth2Credential {
+	return c.fromEnv(name, toEnvironmentKey)
+}
+
+func (c OAuth2Credential) fromEnv(name string, envKey envKeyFunc) *OAuth2Credential {
+	result := OAuth2Credential{
+		Name:         name,
+		TokenURL:     os.Getenv(envKey(name, "tokenURL")),
+		ClientID:     os.Getenv(envKey(name, "clientID")),
+		ClientSecret: os.Getenv(envKey(name, "clientSecret")),
+		Scopes:       splitScopes(os.Getenv(envKey(name, "scopes"))),
+		RefreshToken: os.Getenv(envKey(name, "refreshToken")),
+	}
+	if result.valid() {
+		return &result
+	}
+	return nil
+}
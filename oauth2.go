@@ -0,0 +1,290 @@
+package toolsconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// TokenCacheFile is the name of the token cache file, stored next to the configuration file.
+	TokenCacheFile = "tokencache.yaml"
+	// tokenExpiryDelta is subtracted from the token expiry, so a token is not used shortly before it expires.
+	tokenExpiryDelta = 30 * time.Second
+	// defaultTokenLifetime is the lifetime of tokens without 'expires_in' in the token response.
+	defaultTokenLifetime = time.Hour
+)
+
+// Token is an OAuth2 access token.
+type Token struct {
+	AccessToken  string    `yaml:"accessToken"`
+	TokenType    string    `yaml:"tokenType,omitempty"`
+	RefreshToken string    `yaml:"refreshToken,omitempty"`
+	Expiry       time.Time `yaml:"expiry,omitempty"`
+}
+
+// Valid returns true if the token is set and does not expire within the next 30 seconds.
+// A token without expiry is not valid, e.g. a token cached by older versions.
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return time.Now().Add(tokenExpiryDelta).Before(t.Expiry)
+}
+
+// TokenError is returned if the token endpoint rejects the request.
+type TokenError struct {
+	StatusCode  int
+	ErrorCode   string
+	Description string
+	// ErrorCodes are additional numeric error codes as returned by e.g. the Microsoft identity platform.
+	ErrorCodes []int
+}
+
+func (e *TokenError) Error() string {
+	message := fmt.Sprintf("token request failed with status %d", e.StatusCode)
+	if e.ErrorCode != "" {
+		message += ": " + e.ErrorCode
+	}
+	if e.Description != "" {
+		message += " - " + e.Description
+	}
+	return message
+}
+
+type TokenSourceOption func(*tokenSourceOptions)
+
+type tokenSourceOptions struct {
+	httpClient    *http.Client
+	cacheFile     string
+	disableCache  bool
+	authorityHost string
+}
+
+// WithHTTPClient sets the http client used for the token requests. Default is http.DefaultClient.
+func WithHTTPClient(client *http.Client) TokenSourceOption {
+	return func(o *tokenSourceOptions) {
+		o.httpClient = client
+	}
+}
+
+// WithTokenCacheFile sets the file used to cache tokens. Default is TokenCacheFile next to the configuration file.
+func WithTokenCacheFile(file string) TokenSourceOption {
+	return func(o *tokenSourceOptions) {
+		o.cacheFile = file
+	}
+}
+
+// WithoutTokenCacheFile disables the token cache file. Tokens are only cached in memory.
+func WithoutTokenCacheFile() TokenSourceOption {
+	return func(o *tokenSourceOptions) {
+		o.disableCache = true
+	}
+}
+
+// TokenSource fetches access tokens for an OAuth2Credential. Tokens are cached in memory and in the token cache file,
+// expired tokens are renewed with the refresh token if available, otherwise with the client credentials.
+type TokenSource struct {
+	credential OAuth2Credential
+	options    tokenSourceOptions
+	mutex      sync.Mutex
+	token      *Token
+}
+
+// NewTokenSource creates a token source for the given credential.
+func NewTokenSource(credential OAuth2Credential, options ...TokenSourceOption) *TokenSource {
+	opts := tokenSourceOptions{
+		httpClient: http.DefaultClient,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.cacheFile == "" && !opts.disableCache {
+		cacheFile, err := configSiblingFile(TokenCacheFile)
+		if err != nil {
+			opts.disableCache = true
+		} else {
+			opts.cacheFile = cacheFile
+		}
+	}
+	return &TokenSource{credential: credential, options: opts}
+}
+
+// Token returns a valid access token. A cached token is returned if it is still valid. The token is a copy, changing
+// it does not change the cached token.
+func (s *TokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.token.Valid() {
+		return s.token.copy(), nil
+	}
+	if !s.options.disableCache {
+		if cached := readTokenCache(s.options.cacheFile)[s.cacheKey()]; cached.Valid() {
+			s.token = &cached
+			return s.token.copy(), nil
+		}
+	}
+	token, err := s.refresh(ctx)
+	return token.copy(), err
+}
+
+// Refresh fetches a new access token, regardless of the cached one.
+func (s *TokenSource) Refresh(ctx context.Context) (*Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	token, err := s.refresh(ctx)
+	return token.copy(), err
+}
+
+func (t *Token) copy() *Token {
+	if t == nil {
+		return nil
+	}
+	token := *t
+	return &token
+}
+
+func (s *TokenSource) refresh(ctx context.Context) (*Token, error) {
+	refreshToken := s.credential.RefreshToken
+	if s.token != nil && s.token.RefreshToken != "" {
+		refreshToken = s.token.RefreshToken
+	} else if !s.options.disableCache {
+		if cached, ok := readTokenCache(s.options.cacheFile)[s.cacheKey()]; ok && cached.RefreshToken != "" {
+			refreshToken = cached.RefreshToken
+		}
+	}
+
+	var token *Token
+	var err error
+	if refreshToken != "" {
+		token, err = s.requestToken(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {refreshToken},
+		})
+		var tokenErr *TokenError
+		if errors.As(err, &tokenErr) && tokenErr.ErrorCode == "invalid_grant" && s.credential.ClientSecret != "" {
+			token, err = nil, nil
+		}
+		if token != nil && token.RefreshToken == "" {
+			token.RefreshToken = refreshToken
+		}
+	}
+	if token == nil && err == nil {
+		token, err = s.requestToken(ctx, url.Values{
+			"grant_type": {"client_credentials"},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.token = token
+	if !s.options.disableCache {
+		if err := writeTokenCache(s.options.cacheFile, s.cacheKey(), *token); err != nil {
+			return nil, fmt.Errorf("could not write token cache '%s': %w", s.options.cacheFile, err)
+		}
+	}
+	return token, nil
+}
+
+func (s *TokenSource) requestToken(ctx context.Context, values url.Values) (*Token, error) {
+	values.Set("client_id", s.credential.ClientID)
+	if s.credential.ClientSecret != "" {
+		values.Set("client_secret", s.credential.ClientSecret)
+	}
+	if len(s.credential.Scopes) > 0 {
+		values.Set("scope", strings.Join(s.credential.Scopes, " "))
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.credential.TokenURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := s.options.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var tokenResponse struct {
+		AccessToken      string          `json:"access_token"`
+		TokenType        string          `json:"token_type"`
+		RefreshToken     string          `json:"refresh_token"`
+		ExpiresIn        json.RawMessage `json:"expires_in"`
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+		ErrorCodes       []int           `json:"error_codes"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil && response.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("could not parse token response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK || tokenResponse.Error != "" || tokenResponse.AccessToken == "" {
+		return nil, &TokenError{
+			StatusCode:  response.StatusCode,
+			ErrorCode:   tokenResponse.Error,
+			Description: tokenResponse.ErrorDescription,
+			ErrorCodes:  tokenResponse.ErrorCodes,
+		}
+	}
+
+	token := Token{
+		AccessToken:  tokenResponse.AccessToken,
+		TokenType:    tokenResponse.TokenType,
+		RefreshToken: tokenResponse.RefreshToken,
+	}
+	// expires_in is a number, but some providers return it as string
+	token.Expiry = time.Now().Add(defaultTokenLifetime)
+	if expiresIn, err := strconv.Atoi(strings.Trim(string(tokenResponse.ExpiresIn), `"`)); err == nil && expiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return &token, nil
+}
+
+func (s *TokenSource) cacheKey() string {
+	scopes := append([]string{}, s.credential.Scopes...)
+	sort.Strings(scopes)
+	return s.credential.TokenURL + "|" + s.credential.ClientID + "|" + strings.Join(scopes, " ")
+}
+
+func readTokenCache(file string) map[string]Token {
+	tokens := map[string]Token{}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return tokens
+	}
+	_ = yaml.Unmarshal(content, &tokens)
+	return tokens
+}
+
+func writeTokenCache(file, key string, token Token) error {
+	tokens := readTokenCache(file)
+	for cacheKey, cachedToken := range tokens {
+		if !cachedToken.Valid() && cachedToken.RefreshToken == "" {
+			delete(tokens, cacheKey)
+		}
+	}
+	tokens[key] = token
+	content, err := yaml.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(file, content)
+}
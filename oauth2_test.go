@@ -0,0 +1,134 @@
+package toolsconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenEndpoint struct {
+	requests []map[string]string
+	handler  func(w http.ResponseWriter, form map[string]string)
+}
+
+func newFakeTokenEndpoint(t *testing.T, handler func(w http.ResponseWriter, form map[string]string)) (*fakeTokenEndpoint, *httptest.Server) {
+	endpoint := &fakeTokenEndpoint{handler: handler}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		form := map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		endpoint.requests = append(endpoint.requests, form)
+		w.Header().Set("Content-Type", "application/json")
+		endpoint.handler(w, form)
+	}))
+	t.Cleanup(server.Close)
+	return endpoint, server
+}
+
+func writeTokenResponse(w http.ResponseWriter, status int, body map[string]interface{}) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestTokenSource_ClientCredentials(t *testing.T) {
+	endpoint, server := newFakeTokenEndpoint(t, func(w http.ResponseWriter, form map[string]string) {
+		writeTokenResponse(w, http.StatusOK, map[string]interface{}{"access_token": "token-1", "token_type": "Bearer", "expires_in": 3600})
+	})
+	cacheFile := filepath.Join(t.TempDir(), TokenCacheFile)
+	credential := OAuth2Credential{Name: "api", TokenURL: server.URL, ClientID: "client", ClientSecret: "secret", Scopes: []string{"read", "write"}}
+
+	source := NewTokenSource(credential, WithTokenCacheFile(cacheFile))
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token.AccessToken)
+	require.Len(t, endpoint.requests, 1)
+	require.Equal(t, map[string]string{"grant_type": "client_credentials", "client_id": "client", "client_secret": "secret", "scope": "read write"}, endpoint.requests[0])
+
+	t.Run("MemoryCache", func(t *testing.T) {
+		token, err := source.Token(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "token-1", token.AccessToken)
+		require.Len(t, endpoint.requests, 1)
+	})
+
+	t.Run("FileCache", func(t *testing.T) {
+		info, err := os.Stat(cacheFile)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		token, err := NewTokenSource(credential, WithTokenCacheFile(cacheFile)).Token(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "token-1", token.AccessToken)
+		require.Len(t, endpoint.requests, 1)
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		token.AccessToken = "changed"
+		token, err := source.Token(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "token-1", token.AccessToken)
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		_, err := source.Refresh(context.Background())
+		require.NoError(t, err)
+		require.Len(t, endpoint.requests, 2)
+	})
+}
+
+func TestTokenSource_WithoutExpiry(t *testing.T) {
+	endpoint, server := newFakeTokenEndpoint(t, func(w http.ResponseWriter, form map[string]string) {
+		writeTokenResponse(w, http.StatusOK, map[string]interface{}{"access_token": "token", "token_type": "Bearer"})
+	})
+	cacheFile := filepath.Join(t.TempDir(), TokenCacheFile)
+	credential := OAuth2Credential{Name: "api", TokenURL: server.URL, ClientID: "client", ClientSecret: "secret"}
+
+	token, err := NewTokenSource(credential, WithTokenCacheFile(cacheFile)).Token(context.Background())
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(defaultTokenLifetime), token.Expiry, time.Minute)
+	require.Len(t, endpoint.requests, 1)
+
+	require.NoError(t, writeTokenCache(cacheFile, NewTokenSource(credential).cacheKey(), Token{AccessToken: "cached"}))
+	token, err = NewTokenSource(credential, WithTokenCacheFile(cacheFile)).Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token", token.AccessToken, "cached tokens without expiry are renewed")
+	require.Len(t, endpoint.requests, 2)
+}
+
+func TestTokenSource_RefreshToken(t *testing.T) {
+	endpoint, server := newFakeTokenEndpoint(t, func(w http.ResponseWriter, form map[string]string) {
+		if form["refresh_token"] == "revoked" {
+			writeTokenResponse(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid_grant", "error_description": "refresh token revoked"})
+			return
+		}
+		// expires within the expiry delta, so the next call has to refresh again
+		writeTokenResponse(w, http.StatusOK, map[string]interface{}{"access_token": "access-" + form["refresh_token"], "refresh_token": "rotated", "expires_in": "5"})
+	})
+	credential := OAuth2Credential{Name: "api", TokenURL: server.URL, ClientID: "client", RefreshToken: "initial"}
+	source := NewTokenSource(credential, WithoutTokenCacheFile())
+
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "access-initial", token.AccessToken)
+	require.Equal(t, "refresh_token", endpoint.requests[0]["grant_type"])
+
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "access-rotated", token.AccessToken)
+	require.Len(t, endpoint.requests, 2)
+
+	_, err = NewTokenSource(OAuth2Credential{TokenURL: server.URL, ClientID: "client", RefreshToken: "revoked"}, WithoutTokenCacheFile()).Token(context.Background())
+	var tokenErr *TokenError
+	require.True(t, errors.As(err, &tokenErr))
+	require.Equal(t, http.StatusBadRequest, tokenErr.StatusCode)
+	require.Equal(t, "invalid_grant", tokenErr.ErrorCode)
+}
@@ -1,13 +1,36 @@
 package toolsconfig
 
+import (
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
 type ConfigOption func(*ConfigOptions)
 
+type customRequirement struct {
+	typeName string
+	name     string
+}
+
 type ConfigOptions struct {
 	requiredServers            []string
 	requiredAzureSubscriptions []string
 	requiredGenerics           []string
+	requiredGenericFields      map[string][]string
+	requiredDatabases          []string
+	requiredKubernetes         []string
+	requiredOAuth2             []string
+	requiredCustoms            []customRequirement
+	secretResolvers            map[string]SecretResolver
+	envPrefix                  string
+	envNaming                  EnvNamingStrategy
+	wellKnownEnv               bool
+	mergeEnv                   bool
 	configDirectory            string
 	configFile                 string
+	sharedFavourites           []string
+	confirmPatterns            []string
 	updateConfig               bool
 }
 
@@ -16,6 +39,9 @@ func (c ConfigOptions) requiredConfig() *Config {
 		Servers:            make([]ServerCredential, len(c.requiredServers)),
 		AzureSubscriptions: make([]AzureSubscriptionCredential, len(c.requiredAzureSubscriptions)),
 		Generic:            make([]GenericCredential, len(c.requiredGenerics)),
+		Databases:          make([]DatabaseCredential, len(c.requiredDatabases)),
+		Kubernetes:         make([]KubernetesCredential, len(c.requiredKubernetes)),
+		OAuth2:             make([]OAuth2Credential, len(c.requiredOAuth2)),
 	}
 	for idx, server := range c.requiredServers {
 		config.Servers[idx] = ServerCredential{
@@ -32,6 +58,44 @@ func (c ConfigOptions) requiredConfig() *Config {
 		config.Generic[idx] = GenericCredential{
 			Key: generic,
 		}
+		if fields := c.requiredGenericFields[generic]; len(fields) > 0 {
+			config.Generic[idx].Fields = make(map[string]string, len(fields))
+			for _, field := range fields {
+				config.Generic[idx].Fields[field] = ""
+			}
+		}
+	}
+	for idx, database := range c.requiredDatabases {
+		config.Databases[idx] = DatabaseCredential{
+			Name: database,
+		}
+	}
+	for idx, cluster := range c.requiredKubernetes {
+		config.Kubernetes[idx] = KubernetesCredential{
+			Name: cluster,
+		}
+	}
+	for idx, client := range c.requiredOAuth2 {
+		config.OAuth2[idx] = OAuth2Credential{
+			Name: client,
+		}
+	}
+	for _, custom := range c.requiredCustoms {
+		customType, err := lookupCustomCredentialType(custom.typeName)
+		if err != nil {
+			continue
+		}
+		var node yaml.Node
+		if err := node.Encode(reflect.New(customType.typ).Interface()); err != nil {
+			continue
+		}
+		if config.Custom == nil {
+			config.Custom = map[string]map[string]yaml.Node{}
+		}
+		if config.Custom[custom.typeName] == nil {
+			config.Custom[custom.typeName] = map[string]yaml.Node{}
+		}
+		config.Custom[custom.typeName][custom.name] = node
 	}
 	return &config
 }
@@ -50,10 +114,108 @@ func RequiredSubscription(nameOrIDs string) ConfigOption {
 	}
 }
 
-// RequiredGeneric add the generic credential with given key as required
-func RequiredGeneric(key string) ConfigOption {
+// RequiredGeneric add the generic credential with given key as required. If fields are given, these fields of the
+// generic credential are required instead of the value.
+func RequiredGeneric(key string, fields ...string) ConfigOption {
 	return func(c *ConfigOptions) {
 		c.requiredGenerics = append(c.requiredGenerics, key)
+		if len(fields) > 0 {
+			if c.requiredGenericFields == nil {
+				c.requiredGenericFields = map[string][]string{}
+			}
+			c.requiredGenericFields[key] = append(c.requiredGenericFields[key], fields...)
+		}
+	}
+}
+
+// RequiredDatabase add the database credential with given name as required
+func RequiredDatabase(name string) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.requiredDatabases = append(c.requiredDatabases, name)
+	}
+}
+
+// RequiredKubernetes add the kubernetes credential with given name as required
+func RequiredKubernetes(name string) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.requiredKubernetes = append(c.requiredKubernetes, name)
+	}
+}
+
+// RequiredOAuth2 add the oauth2 credential with given name as required
+func RequiredOAuth2(name string) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.requiredOAuth2 = append(c.requiredOAuth2, name)
+	}
+}
+
+// RequiredCustom add the custom credential of the registered type with given name as required
+func RequiredCustom(typeName, name string) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.requiredCustoms = append(c.requiredCustoms, customRequirement{typeName: typeName, name: name})
+	}
+}
+
+// CustomSecretResolver adds a resolver for secret references with the given scheme, e.g. 'pass' for values like
+// 'pass:email/work'. The built-in schemes 'env', 'file' and 'cmd' can be replaced.
+func CustomSecretResolver(scheme string, resolver SecretResolver) ConfigOption {
+	return func(c *ConfigOptions) {
+		if c.secretResolvers == nil {
+			c.secretResolvers = map[string]SecretResolver{}
+		}
+		c.secretResolvers[scheme] = resolver
+	}
+}
+
+// EnvPrefix sets the prefix of the environment variables of the credentials, e.g. 'mytool' reads the username of the
+// server 'testserver.io' from 'MYTOOL_TESTSERVER_IO_USERNAME'. Default is no prefix.
+func EnvPrefix(prefix string) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.envPrefix = prefix
+	}
+}
+
+// EnvNaming sets the naming strategy of the environment variables of the credentials. Default is DefaultEnvNaming.
+func EnvNaming(strategy EnvNamingStrategy) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.envNaming = strategy
+	}
+}
+
+// WellKnownEnv enables the environment variables of the azure SDKs (AZURE_SUBSCRIPTION_ID, AZURE_TENANT_ID,
+// AZURE_CLIENT_ID, AZURE_CLIENT_SECRET) as fallback for the fields of azure subscription credentials, which are
+// neither set by the environment variables of the entry nor in the config file. Subscriptions without an entry in
+// the config file use them only if requested as the default subscription or by the ID in AZURE_SUBSCRIPTION_ID.
+// Default is 'false'.
+func WellKnownEnv(value bool) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.wellKnownEnv = value
+	}
+}
+
+// MergeEnv enables overriding single fields of a credential from the config file by environment variables, e.g. only
+// the password of a server. Without the option the environment variables are only used if all fields of the
+// credential are set. Default is 'false'.
+func MergeEnv(value bool) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.mergeEnv = value
+	}
+}
+
+// SharedFavourites adds read-only files with favourite bundles, e.g. a file in the repository of a team, see
+// ParseFavouriteBundle. The favourites of the config file take precedence over shared favourites with the same name.
+// Missing files are ignored.
+func SharedFavourites(files ...string) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.sharedFavourites = append(c.sharedFavourites, files...)
+	}
+}
+
+// ConfirmPatterns sets the regular expressions of command lines which must be confirmed before a favourite runs, see
+// RequiresConfirmation. Default is DefaultConfirmPatterns.
+func ConfirmPatterns(patterns ...string) ConfigOption {
+	return func(c *ConfigOptions) {
+		c.confirmPatterns = patterns
 	}
 }
 
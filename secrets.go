@@ -0,0 +1,204 @@
+package toolsconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// SecretResolver resolves the reference of a secret reference value, e.g. 'MY_VAR' of 'env:MY_VAR'.
+type SecretResolver func(reference string) (string, error)
+
+// defaultSecretResolvers returns the resolvers of the built-in reference schemes:
+// * env:MY_VAR (value of the environment variable)
+// * file:/run/secrets/token (content of the file, without trailing newline)
+// * cmd:op read op://vault/item (output of the command, without trailing newline)
+// * raw:env:literal (the literal value after 'raw:', for secrets starting with a scheme)
+func defaultSecretResolvers() map[string]SecretResolver {
+	return map[string]SecretResolver{
+		"env":  resolveEnvReference,
+		"file": resolveFileReference,
+		"cmd":  newCommandResolver(),
+		"raw":  resolveRawReference,
+	}
+}
+
+func resolveRawReference(reference string) (string, error) {
+	return reference, nil
+}
+
+func resolveEnvReference(reference string) (string, error) {
+	value, ok := os.LookupEnv(reference)
+	if !ok {
+		return "", fmt.Errorf("environment variable '%s' not set", reference)
+	}
+	return value, nil
+}
+
+func resolveFileReference(reference string) (string, error) {
+	file := reference
+	if strings.HasPrefix(file, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		file = filepath.Join(home, file[2:])
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// newCommandResolver returns a resolver executing the command with the shell. The output of every command is cached,
+// so each command is executed only once.
+func newCommandResolver() SecretResolver {
+	outputs := map[string]string{}
+	return func(reference string) (string, error) {
+		if output, ok := outputs[reference]; ok {
+			return output, nil
+		}
+		var stdout bytes.Buffer
+		command := shellCommand(reference)
+		command.Stdin = os.Stdin
+		command.Stdout = &stdout
+		command.Stderr = os.Stderr
+		if err := command.Run(); err != nil {
+			return "", fmt.Errorf("command '%s' failed: %w", reference, err)
+		}
+		outputs[reference] = strings.TrimRight(stdout.String(), "\r\n")
+		return outputs[reference], nil
+	}
+}
+
+// IsSecretReference returns true if the value is a secret reference with a built-in scheme, e.g. 'env:MY_VAR' or
+// 'vault:secret/data/app#password'. The schemes added with CustomSecretResolver are not known.
+func IsSecretReference(value string) bool {
+	scheme, _, found := strings.Cut(value, ":")
+	if !found {
+		return false
+	}
+	_, ok := defaultSecretResolvers()[scheme]
+	return ok || scheme == "vault" || scheme == "keyvault"
+}
+
+// secretReference splits a value into the scheme and the reference, if the scheme has a registered resolver.
+func (c *ToolConfiguration) secretReference(value string) (SecretResolver, string, bool) {
+	idx := strings.Index(value, ":")
+	if idx <= 0 {
+		return nil, "", false
+	}
+	resolver, ok := c.secretResolvers[value[:idx]]
+	return resolver, value[idx+1:], ok
+}
+
+// resolveSecret resolves a secret reference. Values without a known scheme are returned unchanged.
+func (c *ToolConfiguration) resolveSecret(value string) (string, error) {
+	resolver, reference, ok := c.secretReference(value)
+	if !ok {
+		return value, nil
+	}
+	resolved, err := resolver(reference)
+	if err != nil {
+		return "", wrapErr(fmt.Errorf("could not resolve secret reference '%s': %w", value, err))
+	}
+	return resolved, nil
+}
+
+// resolveReferences resolves the values of the struct the credential points to: the secret references of the fields
+// tagged with 'secret:"true"' and the variables of all other string values, see resolveString. Values read from
+// environment variables (see Provenance) and the aliases and tags are kept as they are. Maps and slices are copied, so
+// the values of the config file are never changed.
+func (c *ToolConfiguration) resolveReferences(credential interface{}, provenance *Provenance) error {
+	value := reflect.ValueOf(credential).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" || field.Anonymous {
+			continue
+		}
+		name := yamlFieldName(field)
+		if provenance != nil && provenance.Fields[name] == SourceEnv {
+			continue
+		}
+		if err := c.resolveValue(value.Field(i), isSecretField(field), name, provenance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSecretField returns true for struct fields tagged with 'secret:"true"'.
+func isSecretField(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+// isSecretEntryField returns true if the field with the given yaml name is a secret field of the entry, see
+// isSecretField. The named fields of generic credentials are secrets.
+func isSecretEntryField(entry interface{}, field string) bool {
+	value := reflect.Indirect(reflect.ValueOf(entry))
+	for i := 0; i < value.NumField(); i++ {
+		if !value.Type().Field(i).Anonymous && yamlFieldName(value.Type().Field(i)) == field {
+			return isSecretField(value.Type().Field(i))
+		}
+	}
+	_, generic := entry.(*GenericCredential)
+	return generic
+}
+
+// resolveString resolves a value of the config file. The secret reference of a secret value like 'env:MY_VAR' is
+// resolved, the variables of all other values are interpolated. The result of the interpolation is never resolved
+// as secret reference.
+func (c *ToolConfiguration) resolveString(value string, secret bool) (string, error) {
+	if _, _, isReference := c.secretReference(value); secret && isReference {
+		return c.resolveSecret(value)
+	}
+	return c.interpolate(value, nil)
+}
+
+// resolveValue resolves a string value or the string values of a map or slice. The values of maps of named fields
+// read from environment variables are kept.
+func (c *ToolConfiguration) resolveValue(value reflect.Value, secret bool, name string, provenance *Provenance) error {
+	switch value.Kind() {
+	case reflect.String:
+		resolved, err := c.resolveString(value.String(), secret)
+		if err != nil {
+			return err
+		}
+		value.SetString(resolved)
+	case reflect.Map:
+		if value.IsNil() || value.Type().Key().Kind() != reflect.String || value.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		resolved := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			element := reflect.New(value.Type().Elem()).Elem()
+			element.Set(iter.Value())
+			fromEnv := provenance != nil && name == "fields" && provenance.Fields[iter.Key().String()] == SourceEnv
+			if !fromEnv {
+				if err := c.resolveValue(element, secret, name, provenance); err != nil {
+					return err
+				}
+			}
+			resolved.SetMapIndex(iter.Key(), element)
+		}
+		value.Set(resolved)
+	case reflect.Slice:
+		if value.IsNil() || value.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		resolved := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(resolved, value)
+		for i := 0; i < resolved.Len(); i++ {
+			if err := c.resolveValue(resolved.Index(i), secret, name, provenance); err != nil {
+				return err
+			}
+		}
+		value.Set(resolved)
+	}
+	return nil
+}
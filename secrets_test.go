@@ -0,0 +1,102 @@
+package toolsconfig
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretReferences(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(secretFile, []byte("file-secret\n"), 0600))
+	require.NoError(t, os.Setenv("TOOLSCONFIG_TEST_SECRET", "env-secret"))
+	defer os.Unsetenv("TOOLSCONFIG_TEST_SECRET")
+
+	var savedConfig = &Config{
+		Servers: []ServerCredential{
+			{URL: serverURL01, Username: "testusername", Password: "env:TOOLSCONFIG_TEST_SECRET"},
+			{URL: serverURL02, Username: "testusername", Password: "env:TOOLSCONFIG_TEST_MISSING"},
+			{URL: "raw.example.com", Username: "env:TOOLSCONFIG_TEST_SECRET", Password: "raw:env:TOOLSCONFIG_TEST_SECRET"},
+			{URL: "merged.example.com", Username: "testusername", Password: "env:TOOLSCONFIG_TEST_SECRET"},
+		},
+		Generic: []GenericCredential{
+			{Key: generic01, Value: "file:" + secretFile, Fields: map[string]string{"custom": "pass:work"}},
+		},
+	}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+
+	configuration, err := NewToolConfiguration(CustomSecretResolver("pass", func(reference string) (string, error) {
+		return "pass-" + reference, nil
+	}))
+	require.NoError(t, err)
+
+	t.Run("Env", func(t *testing.T) {
+		server, err := configuration.GetServerCredentials(serverURL01)
+		require.NoError(t, err)
+		require.Equal(t, "env-secret", server.Password)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := configuration.GetServerCredentials(serverURL02)
+		require.Error(t, err)
+	})
+
+	t.Run("FileAndCustom", func(t *testing.T) {
+		generic, err := configuration.GetGenericCredentials(generic01)
+		require.NoError(t, err)
+		require.Equal(t, "file-secret", generic.Value)
+		require.Equal(t, "pass-work", generic.Fields["custom"])
+		require.Equal(t, "pass-work", configuration.GetGenericField(generic01, "custom"))
+	})
+
+	t.Run("Command", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses a unix shell command")
+		}
+		require.NoError(t, configuration.SetAzureSubscriptionCredentials(AzureSubscriptionCredential{
+			Name: subscriptionName01, SubscriptionID: "subscription-id", TenantID: "tenant-id", ClientID: "client-id", ClientSecret: "cmd:printf 'cmd-secret\\n'",
+		}))
+		subscription, err := configuration.GetAzureSubscriptionCredentials(subscriptionName01)
+		require.NoError(t, err)
+		require.Equal(t, "cmd-secret", subscription.ClientSecret)
+	})
+
+	t.Run("RawAndNotSecretFields", func(t *testing.T) {
+		server, err := configuration.GetServerCredentials("raw.example.com")
+		require.NoError(t, err)
+		require.Equal(t, "env:TOOLSCONFIG_TEST_SECRET", server.Username)
+		require.Equal(t, "env:TOOLSCONFIG_TEST_SECRET", server.Password)
+	})
+
+	t.Run("EnvValuesNotResolved", func(t *testing.T) {
+		t.Setenv(toEnvironmentKey("merged.example.com", "password"), "cmd:echo injected")
+		configuration, err := NewToolConfiguration(MergeEnv(true))
+		require.NoError(t, err)
+		server, err := configuration.GetServerCredentials("merged.example.com")
+		require.NoError(t, err)
+		require.Equal(t, "cmd:echo injected", server.Password)
+
+		t.Setenv(toEnvironmentKey("env.example.com", "username"), "user")
+		t.Setenv(toEnvironmentKey("env.example.com", "password"), "env:TOOLSCONFIG_TEST_SECRET")
+		server, err = configuration.GetServerCredentials("env.example.com")
+		require.NoError(t, err)
+		require.Equal(t, "env:TOOLSCONFIG_TEST_SECRET", server.Password)
+	})
+
+	t.Run("NotSavedResolved", func(t *testing.T) {
+		require.NoError(t, configuration.SetDefaultSubscription(subscriptionName01))
+		require.Equal(t, "env:TOOLSCONFIG_TEST_SECRET", savedConfig.Servers[0].Password)
+		require.Equal(t, "file:"+secretFile, savedConfig.Generic[0].Value)
+		require.Equal(t, "pass:work", savedConfig.Generic[0].Fields["custom"])
+	})
+}
@@ -0,0 +1,147 @@
+package toolsconfig
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ServerMatchRule is the rule used to match a requested url to a server entry.
+type ServerMatchRule string
+
+const (
+	// MatchExact the normalized urls are equal.
+	MatchExact ServerMatchRule = "exact"
+	// MatchPathPrefix the entry has the same host and the longest path which is a prefix of the requested path.
+	MatchPathPrefix ServerMatchRule = "path-prefix"
+	// MatchHost the entry has the same host and no path.
+	MatchHost ServerMatchRule = "host"
+	// MatchWildcard the entry is a wildcard like '*.example.com' matching the requested host.
+	MatchWildcard ServerMatchRule = "wildcard"
+)
+
+// ServerMatch explains which server entry was found for a requested url.
+type ServerMatch struct {
+	// URL is the requested url.
+	URL string
+	// Entry is the url or alias of the matching entry in the config file.
+	Entry string
+	Rule  ServerMatchRule
+}
+
+func (m ServerMatch) String() string {
+	return fmt.Sprintf("server '%s' matched entry '%s' (%s)", m.URL, m.Entry, m.Rule)
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// serverURL is a normalized url. Scheme and host are lower case, default ports and trailing slashes are removed.
+type serverURL struct {
+	scheme string
+	host   string
+	port   string
+	path   string
+}
+
+func parseServerURL(raw string) serverURL {
+	value := strings.TrimSpace(raw)
+	if !strings.Contains(value, "://") {
+		value = "//" + value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return serverURL{host: strings.ToLower(strings.TrimSuffix(raw, "/"))}
+	}
+	result := serverURL{
+		scheme: strings.ToLower(parsed.Scheme),
+		host:   strings.ToLower(parsed.Hostname()),
+		port:   parsed.Port(),
+		path:   strings.TrimSuffix(parsed.Path, "/"),
+	}
+	if result.port != "" && result.port == defaultPorts[result.scheme] {
+		result.port = ""
+	}
+	return result
+}
+
+// sameOrigin compares scheme and port, a missing scheme matches every scheme. The default port of the scheme of one
+// url matches an explicit port of the other url without scheme, e.g. 'https://host' and 'host:443'.
+func (u serverURL) sameOrigin(other serverURL) bool {
+	if u.scheme != "" && other.scheme != "" && u.scheme != other.scheme {
+		return false
+	}
+	return u.port == other.port ||
+		(u.port == "" && other.scheme == "" && other.port == defaultPorts[u.scheme]) ||
+		(other.port == "" && u.scheme == "" && u.port == defaultPorts[other.scheme])
+}
+
+func (u serverURL) equal(other serverURL) bool {
+	return u.sameOrigin(other) && u.host == other.host && u.path == other.path
+}
+
+// SameServerURL returns true if the urls are equal after normalization, e.g. 'https://Example.com:443/' and
+// 'example.com'. SetServerCredentials replaces the entry with the same url.
+func SameServerURL(a, b string) bool {
+	return a == b || parseServerURL(a).equal(parseServerURL(b))
+}
+
+// hasPathPrefix returns true if the prefix is a prefix of the path at a segment boundary.
+func hasPathPrefix(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// matchServer finds the server entry for the url. The rules are applied in this order, the first rule with a
+// matching entry wins: exact, path-prefix/host (longest path), wildcard (longest domain, then longest path).
+// The aliases of an entry are matched like its url. If several entries are equally specific, the first entry in the
+// config file wins.
+func (c Config) matchServer(requested string) (*ServerCredential, *int, *ServerMatch, error) {
+	request := parseServerURL(requested)
+	bestIndex, bestEntry, bestRule, bestHostLength, bestPathLength := -1, "", ServerMatchRule(""), -1, -1
+	rank := map[ServerMatchRule]int{MatchExact: 3, MatchPathPrefix: 2, MatchHost: 2, MatchWildcard: 1}
+	for index, server := range c.Servers {
+		for _, candidate := range append([]string{server.URL}, server.Aliases...) {
+			entry := parseServerURL(candidate)
+			if !entry.sameOrigin(request) || !hasPathPrefix(request.path, entry.path) {
+				continue
+			}
+			var rule ServerMatchRule
+			switch {
+			case entry.equal(request):
+				rule = MatchExact
+			case entry.host == request.host && entry.path != "":
+				rule = MatchPathPrefix
+			case entry.host == request.host:
+				rule = MatchHost
+			case strings.HasPrefix(entry.host, "*.") && strings.HasSuffix(request.host, entry.host[1:]):
+				rule = MatchWildcard
+			default:
+				continue
+			}
+			better := bestIndex < 0 || rank[rule] > rank[bestRule] ||
+				(rank[rule] == rank[bestRule] && len(entry.host) > bestHostLength) ||
+				(rank[rule] == rank[bestRule] && len(entry.host) == bestHostLength && len(entry.path) > bestPathLength)
+			if better {
+				bestIndex, bestEntry, bestRule, bestHostLength, bestPathLength = index, candidate, rule, len(entry.host), len(entry.path)
+			}
+		}
+	}
+	if bestIndex < 0 {
+		log.WithField("url", requested).Debug("no server entry matched")
+		return nil, nil, nil, wrapErr(errNotFound, "server '"+requested+"'")
+	}
+	server := c.Servers[bestIndex]
+	match := &ServerMatch{URL: requested, Entry: bestEntry, Rule: bestRule}
+	log.Debug(match.String())
+	return &server, &bestIndex, match, nil
+}
+
+// ExplainServerMatch returns which server entry of the config file is used for the given url and why.
+func (c *ToolConfiguration) ExplainServerMatch(url string) (*ServerMatch, error) {
+	_, _, match, err := c.config.matchServer(url)
+	return match, err
+}
@@ -0,0 +1,94 @@
+package toolsconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_matchServer(t *testing.T) {
+	config := Config{
+		Servers: []ServerCredential{
+			{URL: "repo.example.com", Username: "host"},
+			{URL: "https://repo.example.com/maven", Username: "maven"},
+			{URL: "https://repo.example.com/maven/releases/", Username: "releases"},
+			{URL: "*.example.com", Username: "wildcard"},
+			{URL: "*.eu.example.com", Username: "eu-wildcard"},
+			{URL: "http://legacy.example.com:8080", Username: "legacy"},
+			{URL: "Registry.Example.com:443", Username: "registry"},
+		},
+	}
+	tests := []struct {
+		url      string
+		username string
+		rule     ServerMatchRule
+		wantErr  bool
+	}{
+		{url: "repo.example.com", username: "host", rule: MatchExact},
+		{url: "HTTPS://REPO.example.com:443/", username: "host", rule: MatchExact},
+		{url: "https://repo.example.com/maven", username: "maven", rule: MatchExact},
+		{url: "repo.example.com/maven/", username: "maven", rule: MatchExact},
+		{url: "https://repo.example.com/maven/snapshots/com/acme", username: "maven", rule: MatchPathPrefix},
+		{url: "https://repo.example.com/maven/releases/com/acme", username: "releases", rule: MatchPathPrefix},
+		{url: "https://repo.example.com/mavenx", username: "host", rule: MatchHost},
+		{url: "https://repo.example.com/npm", username: "host", rule: MatchHost},
+		{url: "https://git.example.com", username: "wildcard", rule: MatchWildcard},
+		{url: "https://git.eu.example.com/org", username: "eu-wildcard", rule: MatchWildcard},
+		{url: "legacy.example.com:8080/path", username: "legacy", rule: MatchHost},
+		{url: "http://legacy.example.com:8080", username: "legacy", rule: MatchExact},
+		{url: "https://legacy.example.com:8080", wantErr: true},
+		{url: "https://legacy.example.com:9090", wantErr: true},
+		{url: "https://registry.example.com", username: "registry", rule: MatchExact},
+		{url: "example.com", wantErr: true},
+		{url: "other.org", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			server, _, match, err := config.matchServer(tt.url)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.username, server.Username)
+			require.Equal(t, tt.rule, match.Rule)
+			require.Equal(t, tt.url, match.URL)
+			require.Equal(t, server.URL, match.Entry)
+		})
+	}
+}
+
+func TestServerMatchConfiguration(t *testing.T) {
+	var savedConfig = &Config{
+		Servers: []ServerCredential{
+			{URL: "https://repo.example.com/", Username: "user", Password: "password"},
+		},
+	}
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+	configuration, err := NewToolConfiguration(RequiredServer("https://repo.example.com/maven"))
+	require.NoError(t, err)
+	server, err := configuration.GetServerCredentials("repo.example.com/maven/com/acme")
+	require.NoError(t, err)
+	require.Equal(t, "user", server.Username)
+	match, err := configuration.ExplainServerMatch("repo.example.com/maven")
+	require.NoError(t, err)
+	require.Equal(t, "server 'repo.example.com/maven' matched entry 'https://repo.example.com/' (host)", match.String())
+
+	require.NoError(t, configuration.SetServerCredentials(ServerCredential{URL: "https://repo.example.com", Username: "new-user", Password: "new-password"}))
+	require.Len(t, savedConfig.Servers, 1)
+	require.Equal(t, "new-user", savedConfig.Servers[0].Username)
+}
+
+func TestSameServerURL(t *testing.T) {
+	require.True(t, SameServerURL("https://Example.com:443/", "example.com"))
+	require.True(t, SameServerURL("repo.example.com/maven/", "https://repo.example.com/maven"))
+	require.False(t, SameServerURL("https://example.com/a", "https://example.com/b"))
+	require.False(t, SameServerURL("https://example.com", "https://other.com"))
+}
@@ -2,6 +2,9 @@ package toolsconfig
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -22,6 +25,8 @@ type Configuration interface {
 	SetServerCredentials(entry ServerCredential) error
 	// GetServerCredentials get the server credentials.
 	GetServerCredentials(url string) (*ServerCredential, error)
+	// ExplainServerMatch returns which server entry is used for the url and why.
+	ExplainServerMatch(url string) (*ServerMatch, error)
 	// GetAllServerCredentials returns all generic credentials available in config file.
 	GetAllServerCredentials() []ServerCredential
 	// SetGenericCredentials set the generic credentials.
@@ -30,18 +35,78 @@ type Configuration interface {
 	GetGenericCredentials(key string) (*GenericCredential, error)
 	// GetAllGenericCredentials returns all generic credentials available in config file.
 	GetAllGenericCredentials() []GenericCredential
+	// SetDatabaseCredentials set the database credentials.
+	SetDatabaseCredentials(entry DatabaseCredential) error
+	// GetDatabaseCredentials get the database credentials.
+	GetDatabaseCredentials(name string) (*DatabaseCredential, error)
+	// GetAllDatabaseCredentials returns all database credentials available in config file.
+	GetAllDatabaseCredentials() []DatabaseCredential
+	// SetKubernetesCredentials set the kubernetes credentials.
+	SetKubernetesCredentials(entry KubernetesCredential) error
+	// GetKubernetesCredentials get the kubernetes credentials.
+	GetKubernetesCredentials(name string) (*KubernetesCredential, error)
+	// GetAllKubernetesCredentials returns all kubernetes credentials available in config file.
+	GetAllKubernetesCredentials() []KubernetesCredential
+	// SetOAuth2Credentials set the oauth2 credentials.
+	SetOAuth2Credentials(entry OAuth2Credential) error
+	// GetOAuth2Credentials get the oauth2 credentials.
+	GetOAuth2Credentials(name string) (*OAuth2Credential, error)
+	// GetAllOAuth2Credentials returns all oauth2 credentials available in config file.
+	GetAllOAuth2Credentials() []OAuth2Credential
+	// SetCustomCredentials set the credentials of a registered custom credential type.
+	SetCustomCredentials(typeName, name string, value interface{}) error
+	// GetCustomCredentials get the credentials of a registered custom credential type.
+	GetCustomCredentials(typeName, name string, out interface{}) error
+	// GetAllCustomCredentials returns all credentials of a registered custom credential type available in config file.
+	GetAllCustomCredentials(typeName string) map[string]interface{}
+	// FindServers returns all server credentials with the tag, resolved like the Get* methods.
+	FindServers(tag string) ([]ServerCredential, error)
+	// FindAzureSubscriptions returns all azure subscription credentials with the tag, resolved like the Get* methods.
+	FindAzureSubscriptions(tag string) ([]AzureSubscriptionCredential, error)
+	// FindGenerics returns all generic credentials with the tag, resolved like the Get* methods.
+	FindGenerics(tag string) ([]GenericCredential, error)
+	// FindDatabases returns all database credentials with the tag, resolved like the Get* methods.
+	FindDatabases(tag string) ([]DatabaseCredential, error)
+	// FindKubernetes returns all kubernetes credentials with the tag, resolved like the Get* methods.
+	FindKubernetes(tag string) ([]KubernetesCredential, error)
+	// FindOAuth2 returns all oauth2 credentials with the tag, resolved like the Get* methods.
+	FindOAuth2(tag string) ([]OAuth2Credential, error)
+	// Provenance returns where the values of a credential came from (config file or environment variables).
+	Provenance(kind, id string) (*Provenance, error)
+	// Explain returns how a credential is resolved, secret values are masked.
+	Explain(kind, id string, options ...ExplainOption) (*Explanation, error)
+	// EnvBindings returns the environment variables honored for the entries of the config file.
+	EnvBindings() []EnvBinding
 	// GetGeneric ...
 	GetGeneric(key string) string
+	// GetGenericField get a single field of a generic credential.
+	GetGenericField(key, field string) string
 	// SetDefaultSubscription set the default azure subscription.
 	SetDefaultSubscription(subscriptionName string) error
 	// SaveFavourite saves a favourite in the config file.
 	SaveFavourite(tool, name string, args []string) error
+	// SetFavourite adds or replaces a favourite in the config file.
+	SetFavourite(tool string, favourite Favourite) error
 	// GetFavourite get a favourite entry identified by the toolname and it's name.
 	GetFavourite(tool, name string) (*Favourite, error)
 	// GetFavourites get all favourites for a given tool.
 	GetFavourites(tool string) []Favourite
+	// FavouriteTools returns the names of all tools with favourites.
+	FavouriteTools() []string
+	// FavouriteChain returns the favourites run by a favourite or a chain of favourites.
+	FavouriteChain(tool, name string) ([]Favourite, error)
+	// RequiresConfirmation returns why the expanded args of a favourite must be confirmed before running.
+	RequiresConfirmation(favourite Favourite, args []string) (string, error)
+	// RecordFavouriteRun updates the last run time and the run count of a favourite.
+	RecordFavouriteRun(tool, name string) error
+	// RenameFavourite renames a favourite in the config file.
+	RenameFavourite(tool, oldName, newName string) error
 	// RemoveFavourite remove a favourite from the config file.
 	RemoveFavourite(tool, name string) error
+	// ExportFavourites returns a bundle with the favourites of a tool to share them.
+	ExportFavourites(tool string, flags CommandFlags, names ...string) (*FavouriteBundle, error)
+	// ImportFavourites adds the favourites of a bundle to the config file.
+	ImportFavourites(tool string, bundle FavouriteBundle, strategy ImportStrategy, flags CommandFlags) ([]ImportResult, error)
 }
 
 var (
@@ -67,6 +132,8 @@ var NewToolConfiguration = func(options ...ConfigOption) (Configuration, error)
 		return nil, fmt.Errorf(`configuration file location not set (Call toolconfig.ConfigFileLocation("dir", "filename"))`)
 	}
 	opts := ConfigOptions{
+		envNaming:       DefaultEnvNaming,
+		confirmPatterns: DefaultConfirmPatterns,
 		updateConfig:    true,
 		configDirectory: *configDirectory,
 		configFile:      *configFile,
@@ -79,12 +146,37 @@ var NewToolConfiguration = func(options ...ConfigOption) (Configuration, error)
 		servers:            map[string]*ServerCredential{},
 		azureSubscriptions: map[string]*AzureSubscriptionCredential{},
 		generics:           map[string]*GenericCredential{},
+		databases:          map[string]*DatabaseCredential{},
+		kubernetes:         map[string]*KubernetesCredential{},
+		oauth2:             map[string]*OAuth2Credential{},
+		customs:            map[string]interface{}{},
+		secretResolvers:    defaultSecretResolvers(),
+		keyVaultTokens:     map[string]*AzureTokenProvider{},
+		keyVaultResolving:  map[string]bool{},
+		keyVaultSecrets:    map[string]string{},
+		envKey: func(key string, fields ...string) string {
+			return opts.envNaming(opts.envPrefix, key, fields...)
+		},
+		wellKnownEnv:    opts.wellKnownEnv,
+		mergeEnv:        opts.mergeEnv,
+		confirmPatterns: opts.confirmPatterns,
+	}
+	c.secretResolvers["vault"] = c.resolveVaultReference
+	c.secretResolvers["keyvault"] = c.resolveKeyVaultReference
+	for scheme, resolver := range opts.secretResolvers {
+		c.secretResolvers[scheme] = resolver
 	}
 
 	file, err := configFileName(opts.configDirectory, opts.configFile)
 	if err != nil {
 		return nil, wrapErr(err)
 	}
+	c.configFile = *file
+
+	c.sharedFavourites, err = readSharedFavourites(opts.sharedFavourites)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
 
 	err = checkConfigFilePermissions(file)
 	if err != nil {
@@ -110,7 +202,7 @@ var NewToolConfiguration = func(options ...ConfigOption) (Configuration, error)
 	return c, err
 }
 
-func verifyRequiredValues(c Configuration, opts ConfigOptions) error {
+func verifyRequiredValues(c *ToolConfiguration, opts ConfigOptions) error {
 	var missingCredentials []string
 	for _, serverURL := range opts.requiredServers {
 		serverCredential, err := c.GetServerCredentials(serverURL)
@@ -125,76 +217,186 @@ func verifyRequiredValues(c Configuration, opts ConfigOptions) error {
 		}
 	}
 	for _, key := range opts.requiredGenerics {
+		if fields := opts.requiredGenericFields[key]; len(fields) > 0 {
+			for _, field := range fields {
+				if value, err := c.genericField(key, field); err != nil || value == "" {
+					missingCredentials = append(missingCredentials, fmt.Sprintf("GenericCredential: %s %v", key, fields))
+					break
+				}
+			}
+			continue
+		}
 		generic, err := c.GetGenericCredentials(key)
 		if err != nil || !generic.valid() {
 			missingCredentials = append(missingCredentials, fmt.Sprintf("GenericCredential: %s", key))
 		}
 	}
+	for _, name := range opts.requiredDatabases {
+		database, err := c.GetDatabaseCredentials(name)
+		if err != nil || !database.valid() {
+			missingCredentials = append(missingCredentials, fmt.Sprintf("DatabaseCredential: %s", name))
+		}
+	}
+	for _, name := range opts.requiredKubernetes {
+		cluster, err := c.GetKubernetesCredentials(name)
+		if err != nil || !cluster.valid() {
+			missingCredentials = append(missingCredentials, fmt.Sprintf("KubernetesCredential: %s", name))
+		}
+	}
+	for _, name := range opts.requiredOAuth2 {
+		client, err := c.GetOAuth2Credentials(name)
+		if err != nil || !client.valid() {
+			missingCredentials = append(missingCredentials, fmt.Sprintf("OAuth2Credential: %s", name))
+		}
+	}
+	for _, custom := range opts.requiredCustoms {
+		if !c.customCredentialValid(custom.typeName, custom.name) {
+			missingCredentials = append(missingCredentials, fmt.Sprintf("CustomCredential: %s/%s", custom.typeName, custom.name))
+		}
+	}
 	if len(missingCredentials) > 0 {
 		return wrapErr(fmt.Errorf("missing entries"), missingCredentials...)
 	}
 	return nil
 }
 
+// SetAzureSubscriptionCredentials adds the subscription or updates the entry with the same name. Aliases are not
+// matched, the name of an entry is never changed.
 func (c *ToolConfiguration) SetAzureSubscriptionCredentials(entry AzureSubscriptionCredential) error {
 	if entry.Name == "" {
 		return fmt.Errorf("subscription name missing")
 	}
-	_, index, err := c.config.azureSubscriptionCredential(entry.Name)
-	if err != nil {
+	index := c.config.entryIndex("azureSubscriptions", entry.Name)
+	if index < 0 {
 		c.config.AzureSubscriptions = append(c.config.AzureSubscriptions, entry)
 	} else {
-		c.config.AzureSubscriptions[*index].SubscriptionID = entry.SubscriptionID
-		c.config.AzureSubscriptions[*index].TenantID = entry.TenantID
-		c.config.AzureSubscriptions[*index].ClientID = entry.ClientID
-		c.config.AzureSubscriptions[*index].ClientSecret = entry.ClientSecret
+		c.config.AzureSubscriptions[index].SubscriptionID = entry.SubscriptionID
+		c.config.AzureSubscriptions[index].TenantID = entry.TenantID
+		c.config.AzureSubscriptions[index].ClientID = entry.ClientID
+		c.config.AzureSubscriptions[index].ClientSecret = entry.ClientSecret
+		c.config.AzureSubscriptions[index].Labels = entry.Labels
 	}
 	return saveConfiguration(c.config)
 }
 
+// SetServerCredentials adds the server or updates the entry with the same normalized url. Aliases are not matched,
+// the url of an entry is never changed.
 func (c *ToolConfiguration) SetServerCredentials(entry ServerCredential) error {
 	if entry.URL == "" {
 		return fmt.Errorf("server url missing")
 	}
-	_, index, err := c.config.serverCredential(entry.URL)
-	if err != nil {
+	index := c.config.entryIndex("servers", entry.URL)
+	if index < 0 {
 		c.config.Servers = append(c.config.Servers, entry)
 	} else {
-		c.config.Servers[*index].URL = entry.URL
-		c.config.Servers[*index].Username = entry.Username
-		c.config.Servers[*index].Password = entry.Password
+		c.config.Servers[index].Username = entry.Username
+		c.config.Servers[index].Password = entry.Password
+		c.config.Servers[index].Labels = entry.Labels
 	}
 	return saveConfiguration(c.config)
 }
 
+// SetGenericCredentials adds the generic credential or updates the entry with the same key. Aliases are not matched.
 func (c *ToolConfiguration) SetGenericCredentials(entry GenericCredential) error {
 	if entry.Key == "" {
 		return fmt.Errorf("generic credential key missing")
 	}
-	_, index, err := c.config.genericCredential(entry.Key)
-	if err != nil {
+	if err := entry.validFields(); err != nil {
+		return err
+	}
+	index := c.config.entryIndex("generics", entry.Key)
+	if index < 0 {
 		c.config.Generic = append(c.config.Generic, entry)
 	} else {
-		c.config.Generic[*index].Key = entry.Key
-		c.config.Generic[*index].Value = entry.Value
+		c.config.Generic[index].Value = entry.Value
+		c.config.Generic[index].Fields = entry.Fields
+		c.config.Generic[index].Labels = entry.Labels
+	}
+	return saveConfiguration(c.config)
+}
+
+// SetDatabaseCredentials adds the database or replaces the entry with the same name. Aliases are not matched.
+func (c *ToolConfiguration) SetDatabaseCredentials(entry DatabaseCredential) error {
+	if entry.Name == "" {
+		return fmt.Errorf("database name missing")
+	}
+	index := c.config.entryIndex("databases", entry.Name)
+	if index < 0 {
+		c.config.Databases = append(c.config.Databases, entry)
+	} else {
+		c.config.Databases[index] = entry
+	}
+	return saveConfiguration(c.config)
+}
+
+// SetKubernetesCredentials adds the cluster or replaces the entry with the same name. Aliases are not matched.
+func (c *ToolConfiguration) SetKubernetesCredentials(entry KubernetesCredential) error {
+	if entry.Name == "" {
+		return fmt.Errorf("kubernetes name missing")
+	}
+	index := c.config.entryIndex("kubernetes", entry.Name)
+	if index < 0 {
+		c.config.Kubernetes = append(c.config.Kubernetes, entry)
+	} else {
+		c.config.Kubernetes[index] = entry
+	}
+	return saveConfiguration(c.config)
+}
+
+// SetOAuth2Credentials adds the client or replaces the entry with the same name. Aliases are not matched.
+func (c *ToolConfiguration) SetOAuth2Credentials(entry OAuth2Credential) error {
+	if entry.Name == "" {
+		return fmt.Errorf("oauth2 name missing")
+	}
+	index := c.config.entryIndex("oauth2", entry.Name)
+	if index < 0 {
+		c.config.OAuth2 = append(c.config.OAuth2, entry)
+	} else {
+		c.config.OAuth2[index] = entry
 	}
 	return saveConfiguration(c.config)
 }
 
 // GetServerCredentials find the credentials for the given url. Returns errNotFound if not found.
+// The entry is found by the normalized url (exact, longest path prefix, host or wildcard match, see ExplainServerMatch).
 func (c *ToolConfiguration) GetServerCredentials(url string) (*ServerCredential, error) {
-	if fromEnv := (ServerCredential{}.FromEnv(url)); fromEnv != nil {
-		return fromEnv, nil
+	credential, provenance, err := c.lookupServer(url)
+	if err != nil {
+		return nil, err
 	}
-	if serverCred, ok := c.servers[url]; ok {
-		return serverCred, nil
+	resolved := *credential
+	if err := c.resolveReferences(&resolved, provenance); err != nil {
+		return nil, err
 	}
-	credential, _, err := c.config.serverCredential(url)
+	return &resolved, nil
+}
+
+// lookupServer returns the unresolved credentials from the environment, the cache or the config file and where the
+// values came from.
+func (c *ToolConfiguration) lookupServer(url string) (*ServerCredential, *Provenance, error) {
+	if err := c.envCollision("servers", url, serverEnvFields); err != nil {
+		return nil, nil, err
+	}
+	if fromEnv := (ServerCredential{}.fromEnv(url, c.envKey)); fromEnv != nil {
+		return fromEnv, c.newProvenance(SourceEnv, url, serverEnvFields), nil
+	}
+	source := SourceCache
+	credential, ok := c.servers[url]
+	if !ok {
+		source = SourceFile
+		found, _, _, err := c.config.matchServer(url)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.servers[url] = found
+		credential = found
+	}
+	merged := *credential
+	provenance, err := c.mergeEnvFields(&merged, url, serverEnvFields, source)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	c.servers[url] = credential
-	return credential, nil
+	return &merged, provenance, nil
 }
 
 func (c *ToolConfiguration) GetAllServerCredentials() []ServerCredential {
@@ -203,22 +405,76 @@ func (c *ToolConfiguration) GetAllServerCredentials() []ServerCredential {
 
 // GetAzureSubscriptionCredentials find the credentials for the given name or subscription id. Returns errNotFound if not found.
 func (c *ToolConfiguration) GetAzureSubscriptionCredentials(nameOrID string) (*AzureSubscriptionCredential, error) {
-	if fromEnv := (AzureSubscriptionCredential{}.FromEnv(nameOrID)); fromEnv != nil {
-		return fromEnv, nil
+	credential, provenance, err := c.lookupAzureSubscription(nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *credential
+	if err := c.resolveReferences(&resolved, provenance); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// lookupAzureSubscription returns the unresolved credentials from the environment, the cache or the config file and
+// where the values came from.
+func (c *ToolConfiguration) lookupAzureSubscription(nameOrID string) (*AzureSubscriptionCredential, *Provenance, error) {
+	if err := c.envCollision("azureSubscriptions", nameOrID, azureSubscriptionEnvFields); err != nil {
+		return nil, nil, err
+	}
+	if fromEnv := (AzureSubscriptionCredential{}.fromEnv(nameOrID, c.envKey)); fromEnv != nil {
+		return fromEnv, c.newProvenance(SourceEnv, nameOrID, azureSubscriptionEnvFields), nil
 	}
 	searchNameOrId := nameOrID
 	if nameOrID == "" && c.config.DefaultAzureSubscription != "" {
 		searchNameOrId = c.config.DefaultAzureSubscription
 	}
-	if azureCred, ok := c.azureSubscriptions[searchNameOrId]; ok {
-		return azureCred, nil
+	source := SourceCache
+	credential, ok := c.azureSubscriptions[searchNameOrId]
+	if !ok {
+		source = SourceFile
+		found, _, err := c.config.azureSubscriptionCredential(searchNameOrId)
+		if err != nil && !(c.wellKnownEnv && c.wellKnownEnvSubscription(searchNameOrId)) {
+			return nil, nil, err
+		}
+		if err != nil {
+			found = &AzureSubscriptionCredential{Name: searchNameOrId}
+			source = SourceEnv
+		} else {
+			c.azureSubscriptions[searchNameOrId] = found
+		}
+		credential = found
 	}
-	credential, _, err := c.config.azureSubscriptionCredential(searchNameOrId)
+	merged := *credential
+	provenance, err := c.mergeEnvFields(&merged, nameOrID, azureSubscriptionEnvFields, source)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if c.wellKnownEnv && !merged.valid() {
+		fromEnv, fields := merged.withWellKnownEnv()
+		if fromEnv != nil {
+			for _, field := range fields {
+				provenance.Fields[field] = SourceEnv
+				provenance.Variables[field] = wellKnownAzureEnv[field]
+			}
+			return fromEnv, provenance, nil
+		}
+		if _, exists := c.azureSubscriptions[searchNameOrId]; !exists {
+			return nil, nil, wrapErr(errNotFound, "subscription '"+searchNameOrId+"'")
+		}
 	}
-	c.azureSubscriptions[searchNameOrId] = credential
-	return credential, nil
+	return &merged, provenance, nil
+}
+
+// wellKnownEnvSubscription returns true if the well known azure environment variables are the credentials of the
+// subscription without an entry in the config file: the default subscription, or AZURE_SUBSCRIPTION_ID is the
+// requested ID. Other names are not found, so a typo never gets the credentials of the environment.
+func (c *ToolConfiguration) wellKnownEnvSubscription(nameOrID string) bool {
+	if nameOrID == "" || nameOrID == c.config.DefaultAzureSubscription {
+		return true
+	}
+	subscriptionID, ok := os.LookupEnv(wellKnownAzureEnv["subscriptionID"])
+	return ok && subscriptionID == nameOrID
 }
 
 func (c *ToolConfiguration) GetAllAzureSubscriptionCredentials() []AzureSubscriptionCredential {
@@ -226,25 +482,203 @@ func (c *ToolConfiguration) GetAllAzureSubscriptionCredentials() []AzureSubscrip
 }
 
 // GetGenericCredentials find the credentials for the given key. Returns errNotFound if not found.
+// Fields of the credential are overridden by the environment variables of the fields.
 func (c *ToolConfiguration) GetGenericCredentials(key string) (*GenericCredential, error) {
-	if fromEnv := (GenericCredential{}.FromEnv(key)); fromEnv != nil {
-		return fromEnv, nil
-	}
-	if genericCred, ok := c.generics[key]; ok {
-		return genericCred, nil
-	}
-	credential, _, err := c.config.genericCredential(key)
+	credential, provenance, err := c.lookupGeneric(key)
 	if err != nil {
 		return nil, err
 	}
-	c.generics[key] = credential
-	return credential, nil
+	resolved := *credential
+	if err := c.resolveReferences(&resolved, provenance); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// lookupGeneric returns the unresolved credentials from the environment, the cache or the config file and where the
+// values came from. The named fields are always overridden by their environment variables.
+func (c *ToolConfiguration) lookupGeneric(key string) (*GenericCredential, *Provenance, error) {
+	fields := []string{genericValueField}
+	if found, _, err := c.config.genericCredential(key); err == nil {
+		for field := range found.Fields {
+			fields = append(fields, field)
+		}
+	}
+	if err := c.envCollision("generics", key, fields); err != nil {
+		return nil, nil, err
+	}
+	source := SourceCache
+	credential, ok := c.generics[key]
+	if !ok {
+		source = SourceFile
+		found, _, err := c.config.genericCredential(key)
+		if err != nil {
+			if fromEnv := (GenericCredential{}.fromEnv(key, c.envKey)); fromEnv != nil {
+				return fromEnv, c.newProvenance(SourceEnv, key, []string{genericValueField}), nil
+			}
+			return nil, nil, err
+		}
+		if err := found.validFields(); err != nil {
+			return nil, nil, err
+		}
+		c.generics[key] = found
+		credential = found
+	}
+	merged := credential.withKnownFieldsFromEnv(c.envKey)
+	// the value variable overrides the value of the config file, the fields of the entry are kept
+	provenance := c.newProvenance(source, key, []string{genericValueField})
+	if err := overrideEnvFields(merged, provenance, []string{genericValueField}); err != nil {
+		return nil, nil, err
+	}
+	for field := range merged.Fields {
+		provenance.Fields[field] = SourceFile
+		provenance.Variables[field] = c.envKey(key, field)
+		if _, ok := os.LookupEnv(provenance.Variables[field]); ok {
+			provenance.Fields[field] = SourceEnv
+		}
+	}
+	return merged, provenance, nil
 }
 
 func (c *ToolConfiguration) GetAllGenericCredentials() []GenericCredential {
 	return c.config.Generic
 }
 
+// GetDatabaseCredentials find the credentials for the given database name. Returns errNotFound if not found.
+func (c *ToolConfiguration) GetDatabaseCredentials(name string) (*DatabaseCredential, error) {
+	credential, provenance, err := c.lookupDatabase(name)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *credential
+	if err := c.resolveReferences(&resolved, provenance); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// lookupDatabase returns the unresolved credentials from the environment, the cache or the config file and where the
+// values came from.
+func (c *ToolConfiguration) lookupDatabase(name string) (*DatabaseCredential, *Provenance, error) {
+	if err := c.envCollision("databases", name, databaseEnvFields); err != nil {
+		return nil, nil, err
+	}
+	if fromEnv := (DatabaseCredential{}.fromEnv(name, c.envKey)); fromEnv != nil {
+		return fromEnv, c.newProvenance(SourceEnv, name, databaseEnvFields), nil
+	}
+	source := SourceCache
+	credential, ok := c.databases[name]
+	if !ok {
+		source = SourceFile
+		found, _, err := c.config.databaseCredential(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.databases[name] = found
+		credential = found
+	}
+	merged := *credential
+	provenance, err := c.mergeEnvFields(&merged, name, databaseEnvFields, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &merged, provenance, nil
+}
+
+func (c *ToolConfiguration) GetAllDatabaseCredentials() []DatabaseCredential {
+	return c.config.Databases
+}
+
+// GetKubernetesCredentials find the credentials for the given cluster name. Returns errNotFound if not found.
+func (c *ToolConfiguration) GetKubernetesCredentials(name string) (*KubernetesCredential, error) {
+	credential, provenance, err := c.lookupKubernetes(name)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *credential
+	if err := c.resolveReferences(&resolved, provenance); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// lookupKubernetes returns the unresolved credentials from the environment, the cache or the config file and where the
+// values came from.
+func (c *ToolConfiguration) lookupKubernetes(name string) (*KubernetesCredential, *Provenance, error) {
+	if err := c.envCollision("kubernetes", name, kubernetesEnvFields); err != nil {
+		return nil, nil, err
+	}
+	if fromEnv := (KubernetesCredential{}.fromEnv(name, c.envKey)); fromEnv != nil {
+		return fromEnv, c.newProvenance(SourceEnv, name, kubernetesEnvFields), nil
+	}
+	source := SourceCache
+	credential, ok := c.kubernetes[name]
+	if !ok {
+		source = SourceFile
+		found, _, err := c.config.kubernetesCredential(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.kubernetes[name] = found
+		credential = found
+	}
+	merged := *credential
+	provenance, err := c.mergeEnvFields(&merged, name, kubernetesEnvFields, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &merged, provenance, nil
+}
+
+func (c *ToolConfiguration) GetAllKubernetesCredentials() []KubernetesCredential {
+	return c.config.Kubernetes
+}
+
+// GetOAuth2Credentials find the credentials for the given oauth2 client name. Returns errNotFound if not found.
+func (c *ToolConfiguration) GetOAuth2Credentials(name string) (*OAuth2Credential, error) {
+	credential, provenance, err := c.lookupOAuth2(name)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *credential
+	if err := c.resolveReferences(&resolved, provenance); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// lookupOAuth2 returns the unresolved credentials from the environment, the cache or the config file and where the
+// values came from.
+func (c *ToolConfiguration) lookupOAuth2(name string) (*OAuth2Credential, *Provenance, error) {
+	if err := c.envCollision("oauth2", name, oauth2EnvFields); err != nil {
+		return nil, nil, err
+	}
+	if fromEnv := (OAuth2Credential{}.fromEnv(name, c.envKey)); fromEnv != nil {
+		return fromEnv, c.newProvenance(SourceEnv, name, oauth2EnvFields), nil
+	}
+	source := SourceCache
+	credential, ok := c.oauth2[name]
+	if !ok {
+		source = SourceFile
+		found, _, err := c.config.oauth2Credential(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.oauth2[name] = found
+		credential = found
+	}
+	merged := *credential
+	provenance, err := c.mergeEnvFields(&merged, name, oauth2EnvFields, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &merged, provenance, nil
+}
+
+func (c *ToolConfiguration) GetAllOAuth2Credentials() []OAuth2Credential {
+	return c.config.OAuth2
+}
+
 // GetGeneric is a simple call to get only the value of a generic key. Empty string if not exists.
 func (c *ToolConfiguration) GetGeneric(key string) string {
 	credentials, err := c.GetGenericCredentials(key)
@@ -254,6 +688,32 @@ func (c *ToolConfiguration) GetGeneric(key string) string {
 	return credentials.Value
 }
 
+// GetGenericField is a simple call to get only a single field of a generic key. The environment variable of the field
+// (e.g. 'KEY_FIELD') is used if set. Empty string if not exists.
+func (c *ToolConfiguration) GetGenericField(key, field string) string {
+	value, err := c.genericField(key, field)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// genericField returns the resolved field of the generic credential, overridden by the environment variable of the
+// field. An empty string is returned if neither the key nor the environment variable exist.
+func (c *ToolConfiguration) genericField(key, field string) (string, error) {
+	if field == genericValueField {
+		return "", wrapErr(errReservedGenericField, "generic '"+key+"'")
+	}
+	if value, ok := os.LookupEnv(c.envKey(key, field)); ok {
+		return value, nil
+	}
+	credential, _, err := c.lookupGeneric(key)
+	if err != nil {
+		return "", nil
+	}
+	return c.resolveString(credential.Fields[field], true)
+}
+
 // SetDefaultSubscription updates the default subscription value in the configuration. GetAzureSubscriptionCredentials returns the
 // subscription credentials with this name or id if the given identifier is empty.
 func (c *ToolConfiguration) SetDefaultSubscription(subscriptionName string) error {
@@ -270,13 +730,28 @@ func (c *ToolConfiguration) SetDefaultSubscription(subscriptionName string) erro
 }
 
 func (c *ToolConfiguration) SaveFavourite(tool, name string, args []string) error {
+	return c.SetFavourite(tool, Favourite{Name: name, Args: args})
+}
+
+// SetFavourite adds or replaces the favourite of the tool with the name of the favourite. The creation time of a
+// replaced favourite is kept, a new favourite is created now if not set.
+func (c *ToolConfiguration) SetFavourite(tool string, favourite Favourite) error {
+	if favourite.Name == "" {
+		return fmt.Errorf("favourite name missing")
+	}
+	if existing, ok := c.config.Favourites[tool][favourite.Name]; ok && favourite.Created.IsZero() {
+		favourite.Created = existing.Created
+	}
+	if favourite.Created.IsZero() {
+		favourite.Created = time.Now()
+	}
 	if c.config.Favourites == nil {
 		c.config.Favourites = make(map[string]map[string]Favourite, 1)
 	}
 	if c.config.Favourites[tool] == nil {
 		c.config.Favourites[tool] = make(map[string]Favourite, 1)
 	}
-	c.config.Favourites[tool][name] = Favourite{Name: name, Args: args}
+	c.config.Favourites[tool][favourite.Name] = favourite
 	err := saveConfiguration(c.config)
 	if err != nil {
 		return wrapErr(err)
@@ -284,29 +759,88 @@ func (c *ToolConfiguration) SaveFavourite(tool, name string, args []string) erro
 	return nil
 }
 
+// GetFavourite returns the favourite of the tool from the config file or the shared favourites.
 func (c *ToolConfiguration) GetFavourite(tool, name string) (*Favourite, error) {
 	if tool, ok := c.config.Favourites[tool]; ok {
 		if command, ok := tool[name]; ok {
 			return &command, nil
 		}
 	}
+	if command, ok := c.sharedFavourites[tool][name]; ok {
+		return &command, nil
+	}
 	return nil, wrapErr(errNotFound)
 }
 
+// GetFavourites returns the favourites of the tool sorted by name, see SortFavourites for other orders. Shared
+// favourites are included unless the config file has a favourite with the same name.
 func (c *ToolConfiguration) GetFavourites(tool string) []Favourite {
-	if tool, ok := c.config.Favourites[tool]; ok {
-		result := make([]Favourite, len(tool))
-		idx := 0
-		for _, favourite := range tool {
-			result[idx] = favourite
-			idx++
+	result := make([]Favourite, 0, len(c.config.Favourites[tool])+len(c.sharedFavourites[tool]))
+	for _, favourite := range c.config.Favourites[tool] {
+		result = append(result, favourite)
+	}
+	for name, favourite := range c.sharedFavourites[tool] {
+		if _, ok := c.config.Favourites[tool][name]; !ok {
+			result = append(result, favourite)
+		}
+	}
+	SortFavourites(result, FavouriteOrderName)
+	return result
+}
+
+// FavouriteTools returns the sorted names of the tools with favourites in the config file or the shared favourites.
+func (c *ToolConfiguration) FavouriteTools() []string {
+	unique := map[string]bool{}
+	for _, favourites := range []map[string]map[string]Favourite{c.config.Favourites, c.sharedFavourites} {
+		for tool, toolFavourites := range favourites {
+			if len(toolFavourites) > 0 {
+				unique[tool] = true
+			}
 		}
-		return result
 	}
-	return []Favourite{}
+	tools := make([]string, 0, len(unique))
+	for tool := range unique {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// readOnlyFavourite returns an error if the favourite is a shared favourite not overridden in the config file.
+func (c *ToolConfiguration) readOnlyFavourite(tool, name string) error {
+	if _, ok := c.config.Favourites[tool][name]; ok {
+		return nil
+	}
+	if favourite, ok := c.sharedFavourites[tool][name]; ok {
+		return wrapErr(fmt.Errorf("favourite '%s' is shared by '%s' and read-only", name, favourite.SharedFile))
+	}
+	return nil
+}
+
+// RecordFavouriteRun sets the last run time of the favourite to now and increments the run count. Runs of shared
+// favourites are not recorded.
+func (c *ToolConfiguration) RecordFavouriteRun(tool, name string) error {
+	favourite, ok := c.config.Favourites[tool][name]
+	if _, shared := c.sharedFavourites[tool][name]; !ok && shared {
+		return nil
+	}
+	if !ok {
+		return wrapErr(errNotFound, "favourite '"+name+"'")
+	}
+	favourite.LastRun = time.Now()
+	favourite.RunCount++
+	c.config.Favourites[tool][name] = favourite
+	err := saveConfiguration(c.config)
+	if err != nil {
+		return wrapErr(err)
+	}
+	return nil
 }
 
 func (c *ToolConfiguration) RemoveFavourite(tool, name string) error {
+	if err := c.readOnlyFavourite(tool, name); err != nil {
+		return err
+	}
 	if c.config.Favourites == nil {
 		return wrapErr(fmt.Errorf("no saved favourites"))
 	}
@@ -320,3 +854,28 @@ func (c *ToolConfiguration) RemoveFavourite(tool, name string) error {
 	}
 	return nil
 }
+
+// RenameFavourite renames the favourite of the tool. Returns an error if a favourite with the new name exists.
+func (c *ToolConfiguration) RenameFavourite(tool, oldName, newName string) error {
+	if err := c.readOnlyFavourite(tool, oldName); err != nil {
+		return err
+	}
+	favourite, ok := c.config.Favourites[tool][oldName]
+	if !ok {
+		return wrapErr(errNotFound, "favourite '"+oldName+"'")
+	}
+	if newName == "" {
+		return fmt.Errorf("favourite name missing")
+	}
+	if _, exists := c.config.Favourites[tool][newName]; exists {
+		return wrapErr(fmt.Errorf("favourite '%s' already exists", newName))
+	}
+	favourite.Name = newName
+	delete(c.config.Favourites[tool], oldName)
+	c.config.Favourites[tool][newName] = favourite
+	err := saveConfiguration(c.config)
+	if err != nil {
+		return wrapErr(err)
+	}
+	return nil
+}
@@ -467,3 +467,73 @@ func TestFavourites(t *testing.T) {
 		})
 	})
 }
+
+func TestGenericFields(t *testing.T) {
+	const apiKey = "api"
+	var savedConfig = &Config{
+		Generic: []GenericCredential{
+			{Key: apiKey, Fields: map[string]string{"clientID": "client-id", "token": "file-token"}},
+		},
+	}
+	readConfiguration = func() *Config {
+		return savedConfig
+	}
+	saveConfiguration = func(config *Config) error {
+		savedConfig = config
+		return nil
+	}
+
+	t.Run("RequiredFieldsExisting", func(t *testing.T) {
+		configuration, err := NewToolConfiguration(RequiredGeneric(apiKey, "clientID", "token"))
+		require.NoError(t, err)
+		require.Equal(t, "client-id", configuration.GetGenericField(apiKey, "clientID"))
+		require.Equal(t, "file-token", configuration.GetGenericField(apiKey, "token"))
+		require.Equal(t, "", configuration.GetGenericField(apiKey, "unknown"))
+	})
+
+	t.Run("FieldFromEnv", func(t *testing.T) {
+		require.NoError(t, os.Setenv(toEnvironmentKey(apiKey, "token"), "env-token"))
+		defer os.Unsetenv(toEnvironmentKey(apiKey, "token"))
+		configuration, err := NewToolConfiguration(RequiredGeneric(apiKey, "token"))
+		require.NoError(t, err)
+		require.Equal(t, "env-token", configuration.GetGenericField(apiKey, "token"))
+		generic, err := configuration.GetGenericCredentials(apiKey)
+		require.NoError(t, err)
+		require.Equal(t, "env-token", generic.Fields["token"])
+		require.Equal(t, "client-id", generic.Fields["clientID"])
+	})
+
+	t.Run("RequiredFieldsMissing", func(t *testing.T) {
+		_, err := NewToolConfiguration(RequiredGeneric(apiKey, "token", "secret"))
+		require.Error(t, err)
+		var configError *ConfigError
+		require.True(t, errors.As(err, &configError))
+		require.Equal(t, []string{"GenericCredential: api [token secret]"}, configError.Missing)
+		require.Equal(t, map[string]string{"clientID": "client-id", "token": "file-token", "secret": ""}, savedConfig.Generic[0].Fields)
+	})
+
+	t.Run("ValueFromEnvKeepsFields", func(t *testing.T) {
+		t.Setenv(toEnvironmentKey(apiKey, "value"), "env-value")
+		configuration, err := NewToolConfiguration()
+		require.NoError(t, err)
+		generic, err := configuration.GetGenericCredentials(apiKey)
+		require.NoError(t, err)
+		require.Equal(t, "env-value", generic.Value)
+		require.Equal(t, "client-id", generic.Fields["clientID"])
+		require.Equal(t, "client-id", configuration.GetGenericField(apiKey, "clientID"))
+	})
+
+	t.Run("ReservedValueField", func(t *testing.T) {
+		configuration, err := NewToolConfiguration()
+		require.NoError(t, err)
+		err = configuration.SetGenericCredentials(GenericCredential{Key: "reserved", Fields: map[string]string{"value": "x"}})
+		require.Error(t, err)
+		require.Equal(t, "", configuration.GetGenericField(apiKey, "value"))
+
+		savedConfig.Generic = append(savedConfig.Generic, GenericCredential{Key: "reserved", Fields: map[string]string{"value": "x"}})
+		configuration, err = NewToolConfiguration()
+		require.NoError(t, err)
+		_, err = configuration.GetGenericCredentials("reserved")
+		require.Error(t, err)
+	})
+}
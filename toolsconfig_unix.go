@@ -6,6 +6,7 @@ package toolsconfig
 import (
 	"fmt"
 	"os"
+	"os/exec"
 )
 
 func checkConfigFilePermissions(file *string) error {
@@ -23,3 +24,7 @@ func checkConfigFilePermissions(file *string) error {
 	}
 	return nil
 }
+
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("sh", "-c", command)
+}
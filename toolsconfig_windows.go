@@ -4,6 +4,12 @@
 //
 package toolsconfig
 
+import "os/exec"
+
 func checkConfigFilePermissions(file *string) error {
 	return nil
 }
+
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
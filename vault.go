@@ -0,0 +1,231 @@
+package toolsconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultVaultAppRoleMount = "approle"
+	defaultVaultCacheTTL     = 5 * time.Minute
+	// vaultLeaseMargin is subtracted from the lease duration of tokens and secrets, so they are renewed in time.
+	vaultLeaseMargin = 10 * time.Second
+)
+
+// VaultConfig holds the access to a HashiCorp Vault server, used to resolve 'vault:' secret references.
+// Either a token or the role and secret ID of an AppRole is required. The token and the secret ID can be secret
+// references themselves (e.g. 'env:VAULT_TOKEN'). If not set, the environment variables VAULT_ADDR, VAULT_TOKEN and
+// VAULT_NAMESPACE are used.
+type VaultConfig struct {
+	Address      string        `yaml:"address"`
+	Namespace    string        `yaml:"namespace,omitempty"`
+	Token        string        `yaml:"token,omitempty"`
+	RoleID       string        `yaml:"roleID,omitempty"`
+	SecretID     string        `yaml:"secretID,omitempty"`
+	AppRoleMount string        `yaml:"approleMount,omitempty"`
+	CacheTTL     time.Duration `yaml:"cacheTTL,omitempty"`
+}
+
+type vaultSecret struct {
+	data   map[string]interface{}
+	expiry time.Time
+}
+
+// vaultClient reads secrets from the KV version 2 secrets engine. Secrets are cached for their lease duration or the
+// configured cache TTL, AppRole tokens are renewed by a new login when their lease expires.
+type vaultClient struct {
+	config      VaultConfig
+	httpClient  *http.Client
+	mutex       sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	secrets     map[string]vaultSecret
+}
+
+type vaultResponse struct {
+	Data          json.RawMessage `json:"data"`
+	LeaseDuration int             `json:"lease_duration"`
+	Auth          *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// resolveVaultReference resolves references like 'vault:secret/ci/artifactory#password'. The first path element is the
+// mount of the KV engine, the field defaults to 'value'.
+func (c *ToolConfiguration) resolveVaultReference(reference string) (string, error) {
+	path, field := reference, "value"
+	if idx := strings.LastIndex(reference, "#"); idx >= 0 {
+		path, field = reference[:idx], reference[idx+1:]
+	}
+	mount, secretPath, found := strings.Cut(strings.Trim(path, "/"), "/")
+	if !found || secretPath == "" {
+		return "", fmt.Errorf("invalid vault reference '%s', expected 'vault:mount/path#field'", reference)
+	}
+	client, err := c.vaultClient()
+	if err != nil {
+		return "", err
+	}
+	data, err := client.read(mount, secretPath)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("field '%s' not found in vault secret '%s'", field, path)
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+func (c *ToolConfiguration) vaultClient() (*vaultClient, error) {
+	if c.vault != nil {
+		return c.vault, nil
+	}
+	config := VaultConfig{}
+	if c.config.Vault != nil {
+		config = *c.config.Vault
+	}
+	if config.Address == "" {
+		config.Address = os.Getenv("VAULT_ADDR")
+	}
+	if config.Token == "" && config.RoleID == "" {
+		config.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if config.Namespace == "" {
+		config.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if config.AppRoleMount == "" {
+		config.AppRoleMount = defaultVaultAppRoleMount
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = defaultVaultCacheTTL
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("vault address not configured")
+	}
+	if config.Token == "" && (config.RoleID == "" || config.SecretID == "") {
+		return nil, fmt.Errorf("vault token or approle credentials not configured")
+	}
+	// the vault credentials can be references to other secrets, but not to vault itself
+	for _, value := range []*string{&config.Token, &config.RoleID, &config.SecretID} {
+		if strings.HasPrefix(*value, "vault:") {
+			return nil, fmt.Errorf("vault credentials must not reference vault")
+		}
+		resolved, err := c.resolveSecret(*value)
+		if err != nil {
+			return nil, err
+		}
+		*value = resolved
+	}
+	c.vault = &vaultClient{
+		config:     config,
+		httpClient: http.DefaultClient,
+		secrets:    map[string]vaultSecret{},
+	}
+	return c.vault, nil
+}
+
+func (v *vaultClient) read(mount, path string) (map[string]interface{}, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	key := mount + "/" + path
+	if secret, ok := v.secrets[key]; ok && time.Now().Before(secret.expiry) {
+		return secret.data, nil
+	}
+
+	response, status, err := v.request(http.MethodGet, "/v1/"+mount+"/data/"+path, nil)
+	if status == http.StatusForbidden && v.config.RoleID != "" {
+		// the token may be revoked before its lease ends, login again once
+		v.token = ""
+		response, status, err = v.request(http.MethodGet, "/v1/"+mount+"/data/"+path, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read vault secret '%s': %w", key, err)
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(response.Data, &secret); err != nil {
+		return nil, fmt.Errorf("could not parse vault secret '%s': %w", key, err)
+	}
+	ttl := v.config.CacheTTL
+	if lease := time.Duration(response.LeaseDuration) * time.Second; lease > 0 && lease-vaultLeaseMargin < ttl {
+		ttl = lease - vaultLeaseMargin
+	}
+	v.secrets[key] = vaultSecret{data: secret.Data, expiry: time.Now().Add(ttl)}
+	return secret.Data, nil
+}
+
+// currentToken returns the static token or the token of an AppRole login, which is renewed if the lease expired.
+func (v *vaultClient) currentToken() (string, error) {
+	if v.config.RoleID == "" {
+		return v.config.Token, nil
+	}
+	if v.token != "" && (v.tokenExpiry.IsZero() || time.Now().Before(v.tokenExpiry)) {
+		return v.token, nil
+	}
+	body, err := json.Marshal(map[string]string{"role_id": v.config.RoleID, "secret_id": v.config.SecretID})
+	if err != nil {
+		return "", err
+	}
+	response, _, err := v.do(http.MethodPost, "/v1/auth/"+v.config.AppRoleMount+"/login", body, "")
+	if err != nil {
+		return "", fmt.Errorf("vault approle login failed: %w", err)
+	}
+	if response.Auth == nil || response.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault approle login returned no token")
+	}
+	v.token = response.Auth.ClientToken
+	v.tokenExpiry = time.Time{}
+	if response.Auth.LeaseDuration > 0 {
+		v.tokenExpiry = time.Now().Add(time.Duration(response.Auth.LeaseDuration)*time.Second - vaultLeaseMargin)
+	}
+	return v.token, nil
+}
+
+func (v *vaultClient) request(method, path string, body []byte) (*vaultResponse, int, error) {
+	token, err := v.currentToken()
+	if err != nil {
+		return nil, 0, err
+	}
+	return v.do(method, path, body, token)
+}
+
+func (v *vaultClient) do(method, path string, body []byte, token string) (*vaultResponse, int, error) {
+	request, err := http.NewRequest(method, strings.TrimSuffix(v.config.Address, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	if token != "" {
+		request.Header.Set("X-Vault-Token", token)
+	}
+	if v.config.Namespace != "" {
+		request.Header.Set("X-Vault-Namespace", v.config.Namespace)
+	}
+	response, err := v.httpClient.Do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, response.StatusCode, err
+	}
+	var result vaultResponse
+	_ = json.Unmarshal(content, &result)
+	if response.StatusCode != http.StatusOK {
+		return nil, response.StatusCode, fmt.Errorf("status %d %s", response.StatusCode, strings.Join(result.Errors, ", "))
+	}
+	return &result, response.StatusCode, nil
+}
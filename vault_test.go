@@ -0,0 +1,131 @@
+package toolsconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	vaultServerURL       = "artifactory.vault.io"
+	vaultBrokenServerURL = "broken.vault.io"
+	vaultGeneric         = "vault-generic"
+)
+
+func newFakeVault(t *testing.T) (*httptest.Server, *int, *int) {
+	var logins, reads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/auth/approle/login":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			if body["role_id"] != "role" || body["secret_id"] != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+				return
+			}
+			logins++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": "approle-token", "lease_duration": 3600}})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/ci/artifactory":
+			if token := r.Header.Get("X-Vault-Token"); token != "static-token" && token != "approle-token" {
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+				return
+			}
+			reads++
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_duration": 0,
+				"data": map[string]interface{}{
+					"data":     map[string]interface{}{"username": "ci-user", "password": "ci-password", "value": "token-value"},
+					"metadata": map[string]interface{}{"version": 3},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &logins, &reads
+}
+
+func TestVaultReferences(t *testing.T) {
+	server, logins, reads := newFakeVault(t)
+	ConfigFileLocation(".", "unittestconfig.yaml")
+	saveConfiguration = func(config *Config) error {
+		return nil
+	}
+	newConfig := func(vault *VaultConfig) *Config {
+		return &Config{
+			Vault: vault,
+			Servers: []ServerCredential{
+				{URL: vaultServerURL, Username: "ci-user", Password: "vault:secret/ci/artifactory#password"},
+				{URL: vaultBrokenServerURL, Username: "ci-user", Password: "vault:secret/ci/artifactory#unknown"},
+			},
+			Generic: []GenericCredential{
+				{Key: vaultGeneric, Value: "vault:secret/ci/artifactory"},
+			},
+		}
+	}
+
+	t.Run("Token", func(t *testing.T) {
+		readConfiguration = func() *Config {
+			return newConfig(&VaultConfig{Address: server.URL, Token: "static-token"})
+		}
+		configuration, err := NewToolConfiguration(RequiredServer(vaultServerURL), RequiredGeneric(vaultGeneric))
+		require.NoError(t, err)
+		server, err := configuration.GetServerCredentials(vaultServerURL)
+		require.NoError(t, err)
+		require.Equal(t, "ci-user", server.Username)
+		require.Equal(t, "ci-password", server.Password)
+		require.Equal(t, "token-value", configuration.GetGeneric(vaultGeneric))
+		require.Equal(t, 1, *reads)
+		require.Equal(t, 0, *logins)
+	})
+
+	t.Run("AppRole", func(t *testing.T) {
+		readConfiguration = func() *Config {
+			return newConfig(&VaultConfig{Address: server.URL, RoleID: "role", SecretID: "secret"})
+		}
+		configuration, err := NewToolConfiguration(RequiredServer(vaultServerURL))
+		require.NoError(t, err)
+		server, err := configuration.GetServerCredentials(vaultServerURL)
+		require.NoError(t, err)
+		require.Equal(t, "ci-password", server.Password)
+		require.Equal(t, 1, *logins)
+		require.Equal(t, 2, *reads)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		readConfiguration = func() *Config {
+			return newConfig(&VaultConfig{Address: server.URL, Token: "wrong-token"})
+		}
+		configuration, err := NewToolConfiguration()
+		require.NoError(t, err)
+		_, err = configuration.GetServerCredentials(vaultServerURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "permission denied")
+
+		readConfiguration = func() *Config {
+			return newConfig(&VaultConfig{Address: server.URL, Token: "static-token"})
+		}
+		configuration, err = NewToolConfiguration()
+		require.NoError(t, err)
+		_, err = configuration.GetServerCredentials(vaultBrokenServerURL)
+		require.Error(t, err)
+
+		readConfiguration = func() *Config {
+			return newConfig(nil)
+		}
+		t.Setenv("VAULT_ADDR", "")
+		configuration, err = NewToolConfiguration()
+		require.NoError(t, err)
+		_, err = configuration.GetServerCredentials(vaultServerURL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "vault address not configured")
+	})
+}